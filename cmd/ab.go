@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/dorochadev/oneliner/config"
+	"github.com/dorochadev/oneliner/internal/executor"
+	"github.com/dorochadev/oneliner/internal/prompt"
+	"golang.org/x/term"
+)
+
+var (
+	abFlag        bool
+	abModel       string
+	abTemperature string
+)
+
+// abVariants returns the two configs compared by --ab. Variant B differs from
+// A only by --ab-model and --ab-temperature; without either both use the same
+// settings, which still shows how much the answers vary between requests.
+func abVariants(cfg *config.Config) ([2]*config.Config, error) {
+	a, b := *cfg, *cfg
+	// both answers are shown side by side, so neither may stream to the terminal
+	a.Stream, b.Stream = false, false
+
+	if abModel != "" {
+		b.Model = abModel
+	}
+	if abTemperature != "" {
+		t, err := parseSamplingValue("temperature", abTemperature)
+		if err != nil {
+			return [2]*config.Config{}, err
+		}
+		b.Temperature = t
+	}
+	return [2]*config.Config{&a, &b}, nil
+}
+
+// abLabel describes a variant by the settings that can differ between A and B.
+func abLabel(name string, cfg *config.Config) string {
+	label := fmt.Sprintf("%s • %s %s", name, cfg.LLMAPI, cfg.Model)
+	if cfg.Temperature != nil {
+		label += " • temperature " + strconv.FormatFloat(*cfg.Temperature, 'g', -1, 64)
+	}
+	return label
+}
+
+// runAB generates the query once per variant, bypassing the cache, and lets
+// the user pick which of the two commands to run or copy.
+func runAB(cfg *config.Config, args []string) error {
+	variants, err := abVariants(cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx := gatherContext(args, cfg)
+	candidates, err := abCandidates(variants, ctx)
+	if err != nil {
+		return err
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		for _, c := range candidates {
+			fmt.Printf("%s [%s risk]\n%s\n\n", c.Label, c.Risk, c.Command)
+		}
+		return nil
+	}
+
+	m, err := tea.NewProgram(executor.NewSelectionModel(candidates)).Run()
+	if err != nil {
+		return fmt.Errorf("failed to show selection prompt: %w", err)
+	}
+	result := m.(executor.SelectionModel)
+	picked := candidates[result.Selected]
+
+	switch result.Action {
+	case executor.ActionRun:
+		return executeCommand(picked.Command, ctx.Query, variants[result.Selected])
+	case executor.ActionCopy:
+		if err := copyToClipboard(picked.Command); err != nil {
+			return fmt.Errorf("failed to copy to clipboard: %w", err)
+		}
+		fmt.Println(dimStyle.Render("  ✓ copied to clipboard"))
+	default:
+		fmt.Print(cancelStyle.Render("  ✗ CANCELLED"))
+		fmt.Print(" ")
+		fmt.Println(dimStyle.Render("• user aborted"))
+	}
+	return nil
+}
+
+// abCandidates generates one command per variant, labelled A and B with
+// their risk, applying the same refusal and --ascii-only checks as a single
+// generation.
+func abCandidates(variants [2]*config.Config, ctx prompt.Context) ([]executor.Candidate, error) {
+	candidates := make([]executor.Candidate, len(variants))
+	for i, variantCfg := range variants {
+		s := &session{cfg: variantCfg, ctx: ctx}
+		response, err := s.generate()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate command %c: %w", 'A'+i, err)
+		}
+		if err := checkRefusal(response, variantCfg); err != nil {
+			return nil, err
+		}
+		if err := checkASCIIOnly(response); err != nil {
+			return nil, fmt.Errorf("command %c: %w", 'A'+i, err)
+		}
+
+		command, _, _ := parseResponse(response)
+		candidates[i] = executor.Candidate{
+			Label:   abLabel(string(rune('A'+i)), variantCfg),
+			Command: command,
+			Risk:    executor.AssessCommandRisk(command, sudoFlag, variantCfg).Level,
+		}
+	}
+	return candidates, nil
+}
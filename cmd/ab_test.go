@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dorochadev/oneliner/internal/executor"
+	"github.com/dorochadev/oneliner/internal/prompt"
+)
+
+func TestABCandidates(t *testing.T) {
+	defer func(model, temperature string, asciiOnly bool) {
+		abModel, abTemperature, asciiOnlyFlag = model, temperature, asciiOnly
+	}(abModel, abTemperature, asciiOnlyFlag)
+	abModel, abTemperature, asciiOnlyFlag = "model-b", "0.2", false
+
+	cfg, requests := mockOpenAI(t, "ls -la", "rm -rf ./build")
+	cfg.Stream = true
+	variants, err := abVariants(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if variants[0].Stream || variants[1].Stream {
+		t.Error("A/B variants must not stream")
+	}
+
+	ctx := prompt.Context{Query: "list files", OS: "linux", Shell: "bash", CWD: "/tmp"}
+	candidates, err := abCandidates(variants, ctx)
+	if err != nil {
+		t.Fatalf("abCandidates: %v", err)
+	}
+
+	if len(*requests) != 2 {
+		t.Fatalf("sent %d requests, want 2", len(*requests))
+	}
+	if (*requests)[0].Model != "test-model" || (*requests)[1].Model != "model-b" {
+		t.Errorf("requested models %q and %q, want test-model and model-b", (*requests)[0].Model, (*requests)[1].Model)
+	}
+
+	want := []struct {
+		label   string
+		command string
+		risk    executor.RiskLevel
+	}{
+		{"A • openai test-model", "ls -la", executor.RiskNone},
+		{"B • openai model-b • temperature 0.2", "rm -rf ./build", executor.RiskCritical},
+	}
+	for i, w := range want {
+		c := candidates[i]
+		if c.Label != w.label || c.Command != w.command || c.Risk != w.risk {
+			t.Errorf("candidate %d = {%q %q %v}, want {%q %q %v}", i, c.Label, c.Command, c.Risk, w.label, w.command, w.risk)
+		}
+	}
+}
+
+func TestABCandidatesASCIIOnly(t *testing.T) {
+	defer func(model string, asciiOnly bool) { abModel, asciiOnlyFlag = model, asciiOnly }(abModel, asciiOnlyFlag)
+	abModel, asciiOnlyFlag = "", true
+
+	// the second answer hides a no-break space
+	cfg, _ := mockOpenAI(t, "ls -la", "ls\u00a0-la")
+	variants, err := abVariants(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = abCandidates(variants, prompt.Context{Query: "list files", OS: "linux", Shell: "bash"})
+	if err == nil || !strings.Contains(err.Error(), "command B") || !strings.Contains(err.Error(), "U+00A0") {
+		t.Errorf("abCandidates with --ascii-only = %v, want a non-ASCII error for command B", err)
+	}
+}
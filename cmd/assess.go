@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/dorochadev/oneliner/internal/executor"
+	"github.com/spf13/cobra"
+)
+
+var assessCmd = &cobra.Command{
+	Use:   "assess [command]",
+	Short: "Print the risk assessment of a shell command as JSON",
+	Long: "Run the risk check on an existing shell command without generating anything and print " +
+		`the result as JSON, e.g. {"level":"High","reasons":["..."]}. ` +
+		"Quote the command, or pass - (or nothing) to read it from stdin.",
+	Example: `  oneliner assess 'rm -rf ./build'
+  oneliner assess --sudo 'systemctl restart nginx'
+  git diff --cached -U0 | grep '^+' | cut -c2- | oneliner assess`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		command, err := commandFromArgs(args)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		assessment := executor.AssessCommandRisk(command, sudoFlag, cfg)
+		if err := json.NewEncoder(os.Stdout).Encode(assessment); err != nil {
+			return fmt.Errorf("failed to write assessment: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(assessCmd)
+
+	flags := assessCmd.Flags()
+	flags.BoolVar(&sudoFlag, "sudo", false, "Assess the command as if it were run with --sudo")
+	flags.StringArrayVar(&configPaths, "config", nil, "Specify alternative config file, or - to read JSON from stdin (repeatable)")
+	flags.StringVar(&profileName, "profile", "", "Use the named config profile")
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/dorochadev/oneliner/config"
+	"github.com/dorochadev/oneliner/internal/llm"
+)
+
+// batchHeaderPrefix starts an optional first line pinning the provider and
+// model for a batch file, e.g. "#!oneliner provider=claude model=claude-sonnet-4-5".
+const batchHeaderPrefix = "#!oneliner"
+
+// batchHeaderKeys maps header keys to the config field they override. A
+// pinned provider other than the configured one does not get its api_key;
+// its key comes from the provider's own variables, as for a fallback.
+var batchHeaderKeys = map[string]func(cfg *config.Config, value string) error{
+	"provider": func(cfg *config.Config, value string) error {
+		if value == cfg.LLMAPI {
+			return nil
+		}
+		key, err := otherProviderKey(value)
+		if err != nil {
+			return err
+		}
+		cfg.LLMAPI, cfg.APIKey = value, key
+		return nil
+	},
+	"model": func(cfg *config.Config, value string) error {
+		cfg.Model = value
+		return nil
+	},
+}
+
+// parseBatchHeader parses a "#!oneliner key=value ..." line. ok is false when
+// the line is not a header at all.
+func parseBatchHeader(line string) (overrides map[string]string, ok bool, err error) {
+	rest, found := strings.CutPrefix(strings.TrimSpace(line), batchHeaderPrefix)
+	if !found || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+		// e.g. "#!onelinerrc" is just a comment
+		return nil, false, nil
+	}
+
+	overrides = make(map[string]string)
+	for _, field := range strings.Fields(rest) {
+		key, value, valid := strings.Cut(field, "=")
+		if !valid || value == "" {
+			return nil, true, fmt.Errorf("invalid batch header field %q (expected key=value)", field)
+		}
+		if _, known := batchHeaderKeys[key]; !known {
+			return nil, true, fmt.Errorf("unknown batch header key %q (supported: provider, model)", key)
+		}
+		if key == "provider" {
+			value = strings.ToLower(value)
+			if !slices.Contains(llm.Providers, value) {
+				return nil, true, fmt.Errorf("unknown provider %q in batch header (supported: %s)", value, strings.Join(llm.Providers, ", "))
+			}
+		}
+		overrides[key] = value
+	}
+	return overrides, true, nil
+}
+
+// readBatchFile returns the header overrides and the queries in path. Blank
+// lines and '#' comments are skipped.
+func readBatchFile(path string) (map[string]string, []string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open batch file: %w", err)
+	}
+	defer file.Close()
+
+	var overrides map[string]string
+	var queries []string
+
+	scanner := bufio.NewScanner(file)
+	first := true
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if first {
+			first = false
+			h, isHeader, err := parseBatchHeader(line)
+			if err != nil {
+				return nil, nil, err
+			}
+			if isHeader {
+				overrides = h
+				continue
+			}
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		queries = append(queries, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read batch file: %w", err)
+	}
+
+	return overrides, queries, nil
+}
+
+// applyBatchHeader applies the header overrides to cfg and returns them as
+// cache key modifiers, so answers from the default provider are not reused
+// for a pinned provider/model.
+func applyBatchHeader(cfg *config.Config, overrides map[string]string) ([]string, error) {
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var modifiers []string
+	for _, key := range keys {
+		if err := batchHeaderKeys[key](cfg, overrides[key]); err != nil {
+			return nil, err
+		}
+		modifiers = append(modifiers, key+"="+overrides[key])
+	}
+	return modifiers, nil
+}
+
+// runBatch runs every query in the batch file in order. A failing query is
+// reported and the rest still run.
+func runBatch(path string) error {
+	overrides, queries, err := readBatchFile(path)
+	if err != nil {
+		return err
+	}
+	if len(queries) == 0 {
+		return fmt.Errorf("no queries found in %s", path)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	applyShellFlag(cfg)
+
+	modifiers, err := applyBatchHeader(cfg, overrides)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for i, query := range queries {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println(dimStyle.Render("# " + query))
+
+		if err := runQuery(cfg, []string{query}, modifiers); err != nil {
+			if errors.Is(err, errCancelled) {
+				return err
+			}
+			fmt.Fprintln(os.Stderr, cancelStyle.Render("  ✗ "+err.Error()))
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d batch queries failed", failed, len(queries))
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/dorochadev/oneliner/config"
+)
+
+func TestParseBatchHeader(t *testing.T) {
+	tests := []struct {
+		line     string
+		want     map[string]string
+		isHeader bool
+		wantErr  bool
+	}{
+		{"#!oneliner provider=claude model=claude-3-5-sonnet", map[string]string{"provider": "claude", "model": "claude-3-5-sonnet"}, true, false},
+		{"  #!oneliner model=gpt-4o  ", map[string]string{"model": "gpt-4o"}, true, false},
+		{"#!oneliner\tprovider=local", map[string]string{"provider": "local"}, true, false},
+		{"#!oneliner", map[string]string{}, true, false},
+		{"#!oneliner provider=Claude", map[string]string{"provider": "claude"}, true, false},
+		{"#!oneliner provider=chatgpt", nil, true, true},
+		{"#!oneliner provider=", nil, true, true},
+		{"#!oneliner provider", nil, true, true},
+		{"#!oneliner temperature=0", nil, true, true},
+		{"#!onelinerrc", nil, false, false},
+		{"#!/bin/sh", nil, false, false},
+		{"# provider=claude", nil, false, false},
+		{"list large files", nil, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, isHeader, err := parseBatchHeader(tt.line)
+			if (err != nil) != tt.wantErr || isHeader != tt.isHeader || !maps.Equal(got, tt.want) {
+				t.Errorf("parseBatchHeader(%q) = %v, %v, %v; want %v, %v, error %v", tt.line, got, isHeader, err, tt.want, tt.isHeader, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadBatchFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	overrides, queries, err := readBatchFile(write("pinned.txt",
+		"#!oneliner provider=claude model=claude-3-5-sonnet\n\nlist large files\n# a comment\n  show disk usage  \n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"provider": "claude", "model": "claude-3-5-sonnet"}; !maps.Equal(overrides, want) {
+		t.Errorf("overrides = %v, want %v", overrides, want)
+	}
+	if want := []string{"list large files", "show disk usage"}; !slices.Equal(queries, want) {
+		t.Errorf("queries = %q, want %q", queries, want)
+	}
+
+	// a header is only recognised on the first line
+	overrides, queries, err = readBatchFile(write("plain.txt", "list large files\n#!oneliner model=gpt-4o\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if overrides != nil || !slices.Equal(queries, []string{"list large files"}) {
+		t.Errorf("got overrides %v, queries %q; want none and one query", overrides, queries)
+	}
+
+	if _, _, err := readBatchFile(write("bad.txt", "#!oneliner colour=blue\nls\n")); err == nil {
+		t.Error("readBatchFile accepted an unknown header key")
+	}
+}
+
+func TestApplyBatchHeader(t *testing.T) {
+	t.Setenv("ONELINER_API_KEY", "sk-openai")
+	t.Setenv("ANTHROPIC_API_KEY", "sk-ant")
+	t.Setenv("MISTRAL_API_KEY", "")
+
+	tests := []struct {
+		name      string
+		overrides map[string]string
+		provider  string
+		model     string
+		apiKey    string
+		modifiers []string
+		wantErr   bool
+	}{
+		{"none", nil, "openai", "gpt-4o", "sk-openai", nil, false},
+		{"model only", map[string]string{"model": "gpt-4o-mini"}, "openai", "gpt-4o-mini", "sk-openai", []string{"model=gpt-4o-mini"}, false},
+		{"same provider keeps the key", map[string]string{"provider": "openai"}, "openai", "gpt-4o", "sk-openai", []string{"provider=openai"}, false},
+		{
+			"other provider gets its own key",
+			map[string]string{"provider": "claude", "model": "claude-sonnet-4-5"},
+			"claude", "claude-sonnet-4-5", "sk-ant",
+			[]string{"model=claude-sonnet-4-5", "provider=claude"}, false,
+		},
+		{"local needs no key", map[string]string{"provider": "local"}, "local", "gpt-4o", "", []string{"provider=local"}, false},
+		{"other provider without a key", map[string]string{"provider": "mistral"}, "openai", "gpt-4o", "sk-openai", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.LLMAPI, cfg.Model, cfg.APIKey = "openai", "gpt-4o", "sk-openai"
+
+			modifiers, err := applyBatchHeader(cfg, tt.overrides)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyBatchHeader error = %v, want error %v", err, tt.wantErr)
+			}
+			if cfg.LLMAPI != tt.provider || cfg.Model != tt.model || cfg.APIKey != tt.apiKey {
+				t.Errorf("config = %s %s key %q; want %s %s key %q", cfg.LLMAPI, cfg.Model, cfg.APIKey, tt.provider, tt.model, tt.apiKey)
+			}
+			if !slices.Equal(modifiers, tt.modifiers) {
+				t.Errorf("modifiers = %q, want %q", modifiers, tt.modifiers)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/dorochadev/oneliner/config"
+	"github.com/spf13/cobra"
+)
+
+var blacklistCmd = &cobra.Command{
+	Use:   "blacklist",
+	Short: "Manage blacklisted_binaries, the binaries that always need confirmation",
+}
+
+var blacklistListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the blacklisted binaries",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load("")
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		fmt.Println()
+		fmt.Println(headerStyle.Render("  Blacklisted binaries"))
+		fmt.Println()
+		if len(cfg.BlacklistedBinaries) == 0 {
+			fmt.Println(hintStyle.Render("  <none>"))
+		}
+		for _, bin := range cfg.BlacklistedBinaries {
+			fmt.Printf("  %s\n", valueStyle.Render(bin))
+		}
+		fmt.Println()
+		return nil
+	},
+}
+
+var blacklistAddCmd = &cobra.Command{
+	Use:   "add [binary]...",
+	Short: "Add binaries to the blacklist",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load("")
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		var added []string
+		for _, bin := range args {
+			if slices.Contains(cfg.BlacklistedBinaries, bin) {
+				fmt.Println(hintStyle.Render("  • " + bin + " is already blacklisted"))
+				continue
+			}
+			cfg.BlacklistedBinaries = append(cfg.BlacklistedBinaries, bin)
+			added = append(added, bin)
+		}
+		if len(added) == 0 {
+			return nil
+		}
+
+		if err := config.Save("", cfg); err != nil {
+			return fmt.Errorf("failed to write config: %w", err)
+		}
+		for _, bin := range added {
+			fmt.Println(successStyle.Render("  ✓ blacklisted " + bin))
+		}
+		return nil
+	},
+}
+
+var blacklistRemoveCmd = &cobra.Command{
+	Use:   "remove [binary]...",
+	Short: "Remove binaries from the blacklist",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load("")
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		var removed, missing []string
+		for _, bin := range args {
+			i := slices.Index(cfg.BlacklistedBinaries, bin)
+			if i < 0 {
+				missing = append(missing, bin)
+				continue
+			}
+			cfg.BlacklistedBinaries = slices.Delete(cfg.BlacklistedBinaries, i, i+1)
+			removed = append(removed, bin)
+		}
+
+		if len(removed) > 0 {
+			// an empty list would be refilled with the defaults on the next load
+			if len(cfg.BlacklistedBinaries) == 0 {
+				return fmt.Errorf("cannot remove every blacklisted binary; an empty list is reset to the defaults")
+			}
+			if err := config.Save("", cfg); err != nil {
+				return fmt.Errorf("failed to write config: %w", err)
+			}
+			for _, bin := range removed {
+				fmt.Println(successStyle.Render("  ✓ removed " + bin))
+			}
+		}
+
+		if len(missing) > 0 {
+			return fmt.Errorf("not blacklisted: %v", missing)
+		}
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(blacklistCmd)
+	blacklistCmd.AddCommand(blacklistListCmd)
+	blacklistCmd.AddCommand(blacklistAddCmd)
+	blacklistCmd.AddCommand(blacklistRemoveCmd)
+}
@@ -2,14 +2,20 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/dorochadev/oneliner/internal/cache"
+	"github.com/dorochadev/oneliner/internal/executor"
 	"github.com/spf13/cobra"
 )
 
@@ -23,8 +29,20 @@ type cacheEntryWithID struct {
 	ID        string
 	Command   string
 	Timestamp time.Time
+	UseCount  int
+	LastUsed  time.Time
 }
 
+// cacheFileEntry is one entry of commands.json as written by the cache package.
+type cacheFileEntry struct {
+	Command   string    `json:"command"`
+	Timestamp time.Time `json:"timestamp"`
+	UseCount  int       `json:"use_count,omitempty"`
+	LastUsed  time.Time `json:"last_used,omitzero"`
+}
+
+var byFrequencyFlag bool
+
 var cacheCmd = &cobra.Command{
 	Use:   "cache",
 	Short: "Manage oneliner cache",
@@ -72,57 +90,222 @@ var cacheListCmd = &cobra.Command{
 			return nil
 		}
 
-		// Sort by timestamp, newest first
-		sort.Slice(entries, func(i, j int) bool {
-			return entries[i].Timestamp.After(entries[j].Timestamp)
-		})
+		if byFrequencyFlag {
+			// Most used first; the same command reached via different queries counts once
+			entries = mergeDuplicateCommands(entries)
+			sort.Slice(entries, func(i, j int) bool {
+				if entries[i].UseCount != entries[j].UseCount {
+					return entries[i].UseCount > entries[j].UseCount
+				}
+				return entries[i].Timestamp.After(entries[j].Timestamp)
+			})
+		} else {
+			// Sort by timestamp, newest first
+			sort.Slice(entries, func(i, j int) bool {
+				return entries[i].Timestamp.After(entries[j].Timestamp)
+			})
+		}
 
 		fmt.Printf("Found %d cached command(s):\n\n", len(entries))
+		printCacheEntries(entries)
 
-		for _, entry := range entries {
-			// Truncate ID for display
-			shortID := entry.ID[:min(8, len(entry.ID))]
+		fmt.Printf("Use 'oneliner cache show <id>' to view an entry in full\n")
+		fmt.Printf("Use 'oneliner cache rm <id>' to remove a specific entry\n")
+		fmt.Printf("Use 'oneliner cache clear' to clear all entries\n")
+
+		return nil
+	},
+}
+
+var cacheRmCmd = &cobra.Command{
+	Use:   "rm [id]",
+	Short: "Remove a cached command by ID (prefix)",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		idPrefix := args[0]
 
-			// Parse command and explanation
-			command, explanation, _ := parseResponse(entry.Command)
+		cachePath, err := getCachePath()
+		if err != nil {
+			return err
+		}
 
-			// Truncate command if too long
-			displayCmd := command
-			if len(displayCmd) > 80 {
-				displayCmd = displayCmd[:77] + "..."
+		entries, err := loadCacheEntries(cachePath)
+		if err != nil {
+			return err
+		}
+
+		entry, err := findCacheEntry(entries, idPrefix)
+		if err != nil {
+			return err
+		}
+		matchedID := entry.ID
+
+		// Remove the entry from cache
+		if err := deleteCacheEntry(cachePath, matchedID); err != nil {
+			return fmt.Errorf("failed to remove entry: %w", err)
+		}
+
+		fmt.Printf("✓ Removed cached entry: %s\n", idStyle.Render(matchedID[:min(8, len(matchedID))]))
+		return nil
+	},
+}
+
+var cacheShowCmd = &cobra.Command{
+	Use:   "show [id]",
+	Short: "Show a cached command in full by ID (prefix)",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cachePath, err := getCachePath()
+		if err != nil {
+			return err
+		}
+
+		entries, err := loadCacheEntries(cachePath)
+		if err != nil {
+			return err
+		}
+
+		entry, err := findCacheEntry(entries, args[0])
+		if err != nil {
+			return err
+		}
+
+		command, explanation, breakdown := parseResponse(entry.Command)
+
+		fmt.Println()
+		fmt.Println(commandStyle.Render(command))
+		if explanation != "" {
+			printSection("ℹ", "Explanation:", explanation)
+		}
+		if breakdown != "" {
+			printSection("⤷", "Breakdown:", breakdown)
+		}
+
+		timeStr := formatTimestamp(entry.Timestamp)
+		if entry.UseCount > 1 {
+			timeStr = fmt.Sprintf("%s · used %d times", timeStr, entry.UseCount)
+		}
+		fmt.Println()
+		fmt.Printf("%s %s\n", idStyle.Render(entry.ID[:min(8, len(entry.ID))]), timestampStyle.Render(timeStr))
+		fmt.Println()
+
+		return nil
+	},
+}
+
+var cacheRunCmd = &cobra.Command{
+	Use:   "run [id]",
+	Short: "Run a cached command by ID (prefix) with the usual risk checks",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		defer func() {
+			// Execute reports these itself; a usage dump would bury the --yes hint
+			if errors.Is(err, executor.ErrInterrupted) || errors.Is(err, executor.ErrNoTerminal) || errors.Is(err, executor.ErrRiskBlocked) {
+				cmd.SilenceErrors = true
+				cmd.SilenceUsage = true
 			}
+		}()
 
-			// Format timestamp
-			timeStr := formatTimestamp(entry.Timestamp)
+		cfg, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
 
-			fmt.Printf("%s %s\n",
-				idStyle.Render(shortID),
-				queryStyle.Render(displayCmd))
+		cachePath, err := getCachePath()
+		if err != nil {
+			return err
+		}
 
-			if explanation != "" {
-				explainPreview := explanation
-				if len(explainPreview) > 80 {
-					explainPreview = explainPreview[:77] + "..."
-				}
-				fmt.Printf("    %s\n", dimStyle.Render(explainPreview))
+		entries, err := loadCacheEntries(cachePath)
+		if err != nil {
+			return err
+		}
+
+		entry, err := findCacheEntry(entries, args[0])
+		if err != nil {
+			return err
+		}
+
+		command, _, _ := parseResponse(entry.Command)
+		// annotated entries carry '#' comments that should not reach the shell
+		command = stripShellComments(command)
+
+		// re-running a command is a reuse, so it counts towards 'used N times'
+		if commandCache, err := cache.New(cachePath, 0, 0); err != nil {
+			fmt.Fprintln(os.Stderr, dimStyle.Render("  ⚠ failed to open cache: "+err.Error()))
+		} else {
+			recordCacheUse(commandCache, entry.ID)
+		}
+
+		fmt.Println(commandStyle.Render(command))
+		return executeCommand(command, "", cfg)
+	},
+}
+
+var cacheExportCmd = &cobra.Command{
+	Use:   "export [file]",
+	Short: "Write the cache as JSON to a file, or to stdout",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cachePath, err := getCachePath()
+		if err != nil {
+			return err
+		}
+
+		entries, err := loadCacheEntries(cachePath)
+		if err != nil {
+			return err
+		}
+
+		// legacy caches are exported in the current format
+		export := make(map[string]cacheFileEntry, len(entries))
+		for _, entry := range entries {
+			export[entry.ID] = cacheFileEntry{
+				Command:   entry.Command,
+				Timestamp: entry.Timestamp,
+				UseCount:  entry.UseCount,
+				LastUsed:  entry.LastUsed,
 			}
+		}
 
-			fmt.Printf("    %s\n\n", timestampStyle.Render(timeStr))
+		data, err := json.MarshalIndent(export, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal cache: %w", err)
 		}
 
-		fmt.Printf("Use 'oneliner cache rm <id>' to remove a specific entry\n")
-		fmt.Printf("Use 'oneliner cache clear' to clear all entries\n")
+		if len(args) == 0 || args[0] == "-" {
+			fmt.Println(string(data))
+			return nil
+		}
 
+		if err := os.WriteFile(args[0], data, 0600); err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+		fmt.Printf("✓ Exported %d cached command(s) to %s\n", len(export), args[0])
 		return nil
 	},
 }
 
-var cacheRmCmd = &cobra.Command{
-	Use:   "rm [id]",
-	Short: "Remove a cached command by ID (prefix)",
+var cacheImportCmd = &cobra.Command{
+	Use:   "import [file]",
+	Short: "Merge cached commands from an exported JSON file (- for stdin)",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		idPrefix := args[0]
+		var data []byte
+		var err error
+		if args[0] == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(args[0])
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read import file: %w", err)
+		}
+
+		imported, err := parseCacheExport(data)
+		if err != nil {
+			return fmt.Errorf("invalid cache export %s: %w", args[0], err)
+		}
 
 		cachePath, err := getCachePath()
 		if err != nil {
@@ -134,43 +317,208 @@ var cacheRmCmd = &cobra.Command{
 			return err
 		}
 
-		if len(entries) == 0 {
-			return fmt.Errorf("cache is empty")
+		merged := make(map[string]cacheFileEntry, len(entries)+len(imported))
+		for _, entry := range entries {
+			merged[entry.ID] = cacheFileEntry{
+				Command:   entry.Command,
+				Timestamp: entry.Timestamp,
+				UseCount:  entry.UseCount,
+				LastUsed:  entry.LastUsed,
+			}
 		}
 
-		// Find matching ID(s)
-		var matchedID string
-		matchCount := 0
-		for _, entry := range entries {
-			if strings.HasPrefix(entry.ID, idPrefix) {
-				matchedID = entry.ID
-				matchCount++
+		added, skipped := 0, 0
+		for id, entry := range imported {
+			// never clobber an entry that is at least as fresh as the imported one
+			if existing, ok := merged[id]; ok && !entry.Timestamp.After(existing.Timestamp) {
+				skipped++
+				continue
 			}
+			merged[id] = entry
+			added++
 		}
 
-		if matchCount == 0 {
-			return fmt.Errorf("no cached entry found with ID prefix: %s", idPrefix)
+		if err := writeCacheFile(cachePath, merged); err != nil {
+			return err
 		}
 
-		if matchCount > 1 {
-			return fmt.Errorf("ambiguous ID prefix '%s' matches %d entries, please be more specific", idPrefix, matchCount)
+		fmt.Printf("✓ Imported %d cached command(s)", added)
+		if skipped > 0 {
+			fmt.Printf(", skipped %d not newer than the local copy", skipped)
 		}
+		fmt.Println()
+		return nil
+	},
+}
 
-		// Remove the entry from cache
-		if err := deleteCacheEntry(cachePath, matchedID); err != nil {
-			return fmt.Errorf("failed to remove entry: %w", err)
+// parseCacheExport decodes an export and checks every entry looks like one
+// the cache wrote, so a wrong file is rejected before anything is merged.
+func parseCacheExport(data []byte) (map[string]cacheFileEntry, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("expected a JSON object of cache entries: %w", err)
+	}
+
+	entries := make(map[string]cacheFileEntry, len(raw))
+	for id, msg := range raw {
+		if !cacheIDRegex.MatchString(id) {
+			return nil, fmt.Errorf("entry %q: ID is not a cache key", id)
 		}
 
-		fmt.Printf("✓ Removed cached entry: %s\n", idStyle.Render(matchedID[:min(8, len(matchedID))]))
+		var entry cacheFileEntry
+		if err := json.Unmarshal(msg, &entry); err != nil {
+			return nil, fmt.Errorf("entry %s: %w", id[:8], err)
+		}
+		if strings.TrimSpace(entry.Command) == "" {
+			return nil, fmt.Errorf("entry %s: missing command", id[:8])
+		}
+		entries[id] = entry
+	}
+	return entries, nil
+}
+
+// cacheIDRegex matches the hex SHA-256 keys produced by cache.HashQuery.
+var cacheIDRegex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// writeCacheFile replaces the cache file with entries via a temp file, so a
+// failed write never leaves a truncated cache behind.
+func writeCacheFile(cachePath string, entries map[string]cacheFileEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	tempPath := cachePath + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	if err := os.Rename(tempPath, cachePath); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	return nil
+}
+
+var cacheSearchCmd = &cobra.Command{
+	Use:   "search [term]",
+	Short: "Find cached commands whose command or explanation contains term",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cachePath, err := getCachePath()
+		if err != nil {
+			return err
+		}
+
+		entries, err := loadCacheEntries(cachePath)
+		if err != nil {
+			return err
+		}
+
+		matches := searchCacheEntries(entries, args[0])
+		if len(matches) == 0 {
+			fmt.Printf("No cached commands match %q\n", args[0])
+			return nil
+		}
+
+		sort.Slice(matches, func(i, j int) bool {
+			return matches[i].Timestamp.After(matches[j].Timestamp)
+		})
+
+		fmt.Printf("Found %d matching command(s):\n\n", len(matches))
+		printCacheEntries(matches)
+
+		fmt.Printf("Use 'oneliner cache show <id>' to view an entry in full\n")
+		fmt.Printf("Use 'oneliner cache rm <id>' to remove a specific entry\n")
+
 		return nil
 	},
 }
 
+// printCacheEntries prints entries in the short format used by list and search.
+func printCacheEntries(entries []cacheEntryWithID) {
+	for _, entry := range entries {
+		// Truncate ID for display
+		shortID := entry.ID[:min(8, len(entry.ID))]
+
+		// Parse command and explanation
+		command, explanation, _ := parseResponse(entry.Command)
+
+		// Truncate command if too long
+		displayCmd := command
+		if len(displayCmd) > 80 {
+			displayCmd = displayCmd[:77] + "..."
+		}
+
+		// Format timestamp
+		timeStr := formatTimestamp(entry.Timestamp)
+
+		fmt.Printf("%s %s\n",
+			idStyle.Render(shortID),
+			queryStyle.Render(displayCmd))
+
+		if explanation != "" {
+			explainPreview := explanation
+			if len(explainPreview) > 80 {
+				explainPreview = explainPreview[:77] + "..."
+			}
+			fmt.Printf("    %s\n", dimStyle.Render(explainPreview))
+		}
+
+		if entry.UseCount > 1 {
+			timeStr = fmt.Sprintf("%s · used %d times", timeStr, entry.UseCount)
+		}
+		fmt.Printf("    %s\n\n", timestampStyle.Render(timeStr))
+	}
+}
+
+// searchCacheEntries returns the entries whose command or explanation
+// contains term, ignoring case.
+func searchCacheEntries(entries []cacheEntryWithID, term string) []cacheEntryWithID {
+	term = strings.ToLower(term)
+
+	var matches []cacheEntryWithID
+	for _, entry := range entries {
+		command, explanation, _ := parseResponse(entry.Command)
+		if strings.Contains(strings.ToLower(command), term) || strings.Contains(strings.ToLower(explanation), term) {
+			matches = append(matches, entry)
+		}
+	}
+	return matches
+}
+
 func init() {
 	rootCmd.AddCommand(cacheCmd)
 	cacheCmd.AddCommand(cacheClearCmd)
 	cacheCmd.AddCommand(cacheListCmd)
 	cacheCmd.AddCommand(cacheRmCmd)
+	cacheCmd.AddCommand(cacheShowCmd)
+	cacheCmd.AddCommand(cacheSearchCmd)
+	cacheCmd.AddCommand(cacheRunCmd)
+	cacheCmd.AddCommand(cacheExportCmd)
+	cacheCmd.AddCommand(cacheImportCmd)
+
+	cacheListCmd.Flags().BoolVar(&byFrequencyFlag, "by-frequency", false, "Sort by how often each command was used")
+	if runtime.GOOS != "windows" {
+		cacheRunCmd.Flags().BoolVar(&sudoFlag, "sudo", false, "Prepend 'sudo' to the command when executing")
+	}
+	cacheRunCmd.Flags().BoolVarP(&yesFlag, "yes", "y", false, "Answer yes to the consent, risk and sudo prompts")
+	cacheRunCmd.Flags().BoolVar(&noAuditFlag, "no-audit", false, "Don't record the executed command in the audit log")
+	cacheRunCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "Assess and print the final command without running it")
+	cacheRunCmd.Flags().BoolVarP(&quietFlag, "quiet", "q", false, "Suppress status lines such as the success/timing line")
+	cacheRunCmd.Flags().StringArrayVar(&configPaths, "config", nil, "Specify alternative config file, or - to read JSON from stdin (repeatable)")
+	cacheRunCmd.Flags().StringVar(&profileName, "profile", "", "Use the named config profile")
+}
+
+// recordCacheUse counts a reuse of the entry at key. It is best-effort: a
+// cache that cannot be written only gets a warning, the command is still used.
+func recordCacheUse(c *cache.Cache, key string) {
+	if err := c.RecordUse(key); err != nil {
+		fmt.Fprintln(os.Stderr, dimStyle.Render("  ⚠ failed to write to cache: "+err.Error()))
+	}
 }
 
 func getCachePath() (string, error) {
@@ -206,10 +554,7 @@ func loadCacheEntries(cachePath string) ([]cacheEntryWithID, error) {
 	}
 
 	// Try new format first
-	var cacheData map[string]struct {
-		Command   string    `json:"command"`
-		Timestamp time.Time `json:"timestamp"`
-	}
+	var cacheData map[string]cacheFileEntry
 
 	if err := json.Unmarshal(data, &cacheData); err != nil {
 		// Try legacy format
@@ -225,6 +570,7 @@ func loadCacheEntries(cachePath string) ([]cacheEntryWithID, error) {
 				ID:        id,
 				Command:   cmd,
 				Timestamp: time.Time{}, // unknown timestamp for legacy
+				UseCount:  1,
 			})
 		}
 		return entries, nil
@@ -236,22 +582,75 @@ func loadCacheEntries(cachePath string) ([]cacheEntryWithID, error) {
 			ID:        id,
 			Command:   entry.Command,
 			Timestamp: entry.Timestamp,
+			UseCount:  max(entry.UseCount, 1),
+			LastUsed:  entry.LastUsed,
 		})
 	}
 
 	return entries, nil
 }
 
+// findCacheEntry returns the single entry whose ID starts with idPrefix.
+func findCacheEntry(entries []cacheEntryWithID, idPrefix string) (cacheEntryWithID, error) {
+	if len(entries) == 0 {
+		return cacheEntryWithID{}, fmt.Errorf("cache is empty")
+	}
+
+	// Find matching ID(s)
+	var matched cacheEntryWithID
+	matchCount := 0
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.ID, idPrefix) {
+			matched = entry
+			matchCount++
+		}
+	}
+
+	if matchCount == 0 {
+		return cacheEntryWithID{}, fmt.Errorf("no cached entry found with ID prefix: %s", idPrefix)
+	}
+
+	if matchCount > 1 {
+		return cacheEntryWithID{}, fmt.Errorf("ambiguous ID prefix '%s' matches %d entries, please be more specific", idPrefix, matchCount)
+	}
+
+	return matched, nil
+}
+
+// mergeDuplicateCommands folds entries with the same command into one, summing
+// their use counts and keeping the most recent entry's ID and timestamp.
+func mergeDuplicateCommands(entries []cacheEntryWithID) []cacheEntryWithID {
+	merged := make([]cacheEntryWithID, 0, len(entries))
+	index := make(map[string]int, len(entries))
+
+	for _, entry := range entries {
+		command, _, _ := parseResponse(entry.Command)
+		command = strings.TrimSpace(command)
+
+		i, seen := index[command]
+		if !seen {
+			index[command] = len(merged)
+			merged = append(merged, entry)
+			continue
+		}
+
+		count := merged[i].UseCount + entry.UseCount
+		if entry.Timestamp.After(merged[i].Timestamp) {
+			merged[i] = entry
+		}
+		merged[i].UseCount = count
+	}
+
+	return merged
+}
+
 func deleteCacheEntry(cachePath string, idToRemove string) error {
 	data, err := os.ReadFile(cachePath)
 	if err != nil {
 		return fmt.Errorf("failed to read cache file: %w", err)
 	}
 
-	var cacheData map[string]struct {
-		Command   string    `json:"command"`
-		Timestamp time.Time `json:"timestamp"`
-	}
+	var cacheData map[string]cacheFileEntry
 
 	if err := json.Unmarshal(data, &cacheData); err != nil {
 		// Try legacy format
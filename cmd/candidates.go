@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/dorochadev/oneliner/config"
+	"github.com/dorochadev/oneliner/internal/executor"
+	"github.com/dorochadev/oneliner/internal/llm"
+	"github.com/dorochadev/oneliner/internal/prompt"
+	"golang.org/x/term"
+)
+
+var candidatesFlag int
+
+// maxCandidates bounds --candidates; more choices cost tokens without helping.
+const maxCandidates = 5
+
+// generateCandidates returns up to n distinct answers to the query. Providers
+// that support it answer in one request; others are asked n times in turn.
+func (s *session) generateCandidates(n int) ([]string, error) {
+	s.usage, s.hasUsage = llm.Usage{}, false
+
+	var responses []string
+	if multi, llmInstance, ok := s.multiGenerator(); ok {
+		s.warnDeprecatedModel()
+		promptText, err := prompt.Build(s.ctx, s.cfg, s.promptOptions())
+		if err != nil {
+			return nil, fmt.Errorf("failed to build prompt: %w", err)
+		}
+		err = withSpinner(llmInstance, func(ctx context.Context) (err error) {
+			responses, err = multi.GenerateCommands(ctx, promptText, n)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		s.recordUsage(llmInstance)
+	} else {
+		for range n {
+			usage, hasUsage := s.usage, s.hasUsage
+			response, err := s.generate()
+			if err != nil {
+				return nil, err
+			}
+			// generate resets usage per call; add the calls up instead
+			s.usage.PromptTokens += usage.PromptTokens
+			s.usage.CompletionTokens += usage.CompletionTokens
+			s.usage.TotalTokens += usage.TotalTokens
+			s.hasUsage = s.hasUsage || hasUsage
+			responses = append(responses, response)
+		}
+	}
+
+	return distinctResponses(responses, s.cfg), nil
+}
+
+// multiGenerator returns the configured provider if it can produce several
+// answers in one request.
+func (s *session) multiGenerator() (llm.MultiGenerator, llm.LLM, bool) {
+	llmInstance, err := llm.New(s.cfg)
+	if err != nil {
+		return nil, nil, false
+	}
+	multi, ok := llmInstance.(llm.MultiGenerator)
+	return multi, llmInstance, ok
+}
+
+// distinctResponses drops refusals and answers whose command repeats an
+// earlier one.
+func distinctResponses(responses []string, cfg *config.Config) []string {
+	seen := make(map[string]bool)
+	var distinct []string
+	for _, response := range responses {
+		if checkRefusal(response, cfg) != nil {
+			continue
+		}
+		command, _, _ := parseResponse(response)
+		key := strings.TrimSpace(command)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		distinct = append(distinct, response)
+	}
+	return distinct
+}
+
+// pickCandidate lets the user choose one of responses. ok is false when they
+// cancelled. Copying from the list copies and ends the run, like the palette.
+func pickCandidate(responses []string, cfg *config.Config) (response string, ok bool, err error) {
+	if len(responses) == 1 || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return responses[0], true, nil
+	}
+
+	candidates := make([]executor.Candidate, len(responses))
+	for i, response := range responses {
+		command, _, _ := parseResponse(response)
+		candidates[i] = executor.Candidate{
+			Label:   fmt.Sprintf("candidate %d", i+1),
+			Command: command,
+			Risk:    executor.AssessCommandRisk(command, sudoFlag, cfg).Level,
+		}
+	}
+
+	model := executor.NewSelectionModel(candidates)
+	model.EnterLabel = "select"
+	m, err := tea.NewProgram(model).Run()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to show selection prompt: %w", err)
+	}
+	result := m.(executor.SelectionModel)
+
+	switch result.Action {
+	case executor.ActionRun:
+		return responses[result.Selected], true, nil
+	case executor.ActionCopy:
+		if err := copyToClipboard(candidates[result.Selected].Command); err != nil {
+			return "", false, fmt.Errorf("failed to copy to clipboard: %w", err)
+		}
+		fmt.Println(dimStyle.Render("  ✓ copied to clipboard"))
+		return "", false, nil
+	default:
+		fmt.Print(cancelStyle.Render("  ✗ CANCELLED"))
+		fmt.Print(" ")
+		fmt.Println(dimStyle.Render("• user aborted"))
+		return "", false, nil
+	}
+}
+
+// runCandidates generates several answers, skipping the cache lookup, and
+// hands the chosen one to the normal display, clipboard and run handling.
+func runCandidates(s *session, n int) error {
+	responses, err := s.generateCandidates(n)
+	if err != nil {
+		if errors.Is(err, errCancelled) {
+			return err
+		}
+		return fmt.Errorf("failed to generate command: %w", err)
+	}
+	if len(responses) == 0 {
+		return errRefusal
+	}
+
+	response, ok, err := pickCandidate(responses, s.cfg)
+	if err != nil || !ok {
+		return err
+	}
+	if err := checkASCIIOnly(response); err != nil {
+		return err
+	}
+	if err := s.cacheResponse(response); err != nil {
+		return err
+	}
+	return handleGeneratedCommand(response, s)
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// smartQuoteReplacer turns typographic quotes, which some models emit, back
+// into the ASCII quotes the shell understands.
+var smartQuoteReplacer = strings.NewReplacer(
+	"‘", "'", "’", "'", "‚", "'", "‛", "'",
+	"“", `"`, "”", `"`, "„", `"`, "‟", `"`,
+)
+
+// runeNames labels the invisible characters most often pasted into commands.
+var runeNames = map[rune]string{
+	'\u00a0': "no-break space",
+	'\u200b': "zero-width space",
+	'\u200c': "zero-width non-joiner",
+	'\u200d': "zero-width joiner",
+	'\u2060': "word joiner",
+	'\ufeff': "byte order mark",
+	'\u202f': "narrow no-break space",
+	'\u2009': "thin space",
+}
+
+// normalizeQuotes replaces smart quotes in a command with ASCII quotes.
+func normalizeQuotes(command string) string {
+	return smartQuoteReplacer.Replace(command)
+}
+
+// invisibleRunes lists characters in command that render as nothing or as a
+// plain space but are not one, e.g. "U+00A0 (no-break space)".
+func invisibleRunes(command string) []string {
+	var found []string
+	seen := make(map[rune]bool)
+	for _, r := range command {
+		if r == ' ' || r == '\t' || r == '\n' || seen[r] {
+			continue
+		}
+		_, named := runeNames[r]
+		if !named && !unicode.Is(unicode.Cf, r) && !unicode.IsSpace(r) && !unicode.IsControl(r) {
+			continue
+		}
+		seen[r] = true
+		found = append(found, describeRune(r))
+	}
+	return found
+}
+
+// nonASCIIRunes lists every distinct non-ASCII character in command.
+func nonASCIIRunes(command string) []string {
+	var found []string
+	seen := make(map[rune]bool)
+	for _, r := range command {
+		if r <= unicode.MaxASCII || seen[r] {
+			continue
+		}
+		seen[r] = true
+		found = append(found, describeRune(r))
+	}
+	return found
+}
+
+func describeRune(r rune) string {
+	if name, ok := runeNames[r]; ok {
+		return fmt.Sprintf("U+%04X (%s)", r, name)
+	}
+	if unicode.IsPrint(r) {
+		return fmt.Sprintf("U+%04X (%c)", r, r)
+	}
+	return fmt.Sprintf("U+%04X", r)
+}
+
+// checkASCIIOnly fails for --ascii-only when the command still contains
+// non-ASCII characters after quote normalization.
+func checkASCIIOnly(response string) error {
+	if !asciiOnlyFlag {
+		return nil
+	}
+	command, _, _ := parseResponse(response)
+	if found := nonASCIIRunes(command); len(found) > 0 {
+		return fmt.Errorf("command contains non-ASCII characters: %s", strings.Join(found, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeQuotes(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{`grep “error” log.txt`, `grep "error" log.txt`},
+		{`echo ‘hi’`, `echo 'hi'`},
+		{`echo „low” ‚low’`, `echo "low" 'low'`},
+		{`echo "plain" 'ascii'`, `echo "plain" 'ascii'`},
+		{"ls\u200b-la", "ls\u200b-la"}, // invisible characters are reported, not rewritten
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			if got := normalizeQuotes(tt.command); got != tt.want {
+				t.Errorf("normalizeQuotes(%q) = %q, want %q", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvisibleRunes(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+	}{
+		{"ascii", "ls -la\tfoo\nbar", nil},
+		{"zero-width space", "ls\u200b -la", []string{"U+200B (zero-width space)"}},
+		{"no-break space", "ls\u00a0-la", []string{"U+00A0 (no-break space)"}},
+		{"reported once", "a\u200bb\u200bc\u00a0d", []string{"U+200B (zero-width space)", "U+00A0 (no-break space)"}},
+		{"unnamed format character", "ls\u2063-la", []string{"U+2063"}},
+		{"visible non-ASCII", "echo café", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := invisibleRunes(tt.command); !slices.Equal(got, tt.want) {
+				t.Errorf("invisibleRunes(%q) = %q, want %q", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckASCIIOnly(t *testing.T) {
+	defer func(v bool) { asciiOnlyFlag = v }(asciiOnlyFlag)
+
+	tests := []struct {
+		name     string
+		response string
+		asciiOn  bool
+		wantErr  string
+	}{
+		{"flag off", "ls\u200b -la", false, ""},
+		{"ascii", "ls -la\nEXPLANATION: lists “all” files", true, ""},
+		{"smart quotes are normalized first", "grep “error” log.txt", true, ""},
+		{"zero-width space", "ls\u200b -la", true, "U+200B (zero-width space)"},
+		{"visible non-ASCII", "echo café", true, "U+00E9 (é)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asciiOnlyFlag = tt.asciiOn
+			err := checkASCIIOnly(tt.response)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("checkASCIIOnly(%q) = %v, want nil", tt.response, err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("checkASCIIOnly(%q) = %v, want an error naming %s", tt.response, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,18 +1,21 @@
 package cmd
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"reflect"
 	"runtime"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/dorochadev/oneliner/config"
+	"github.com/dorochadev/oneliner/internal/llm"
 	"github.com/spf13/cobra"
 )
 
@@ -38,76 +41,24 @@ var setCmd = &cobra.Command{
 		key := args[0]
 		value := args[1]
 
-		cfgPath := "" // use default
-		cfg, err := config.Load(cfgPath)
+		cfg, err := config.Load("")
 		if err != nil {
 			return fmt.Errorf("failed to load config: %w", err)
 		}
 
-		// Store old value for display
-		oldValue := ""
-
-		// reflect over Config struct to set field dynamically
-		v := reflect.ValueOf(cfg).Elem()
-		t := v.Type()
-		found := false
-		for i := 0; i < v.NumField(); i++ {
-			field := t.Field(i)
-			jsonTag := field.Tag.Get("json")
-			if jsonTag == key {
-				fieldVal := v.FieldByName(field.Name)
-				if fieldVal.CanSet() {
-					// Store old value
-					switch fieldVal.Kind() {
-					case reflect.String:
-						oldValue = fieldVal.String()
-					case reflect.Int:
-						oldValue = strconv.Itoa(int(fieldVal.Int()))
-					}
-
-					// Set new value
-					switch fieldVal.Kind() {
-					case reflect.String:
-						if jsonTag == "local_llm_endpoint" && value != "" {
-							if !strings.HasPrefix(value, "http://") && !strings.HasPrefix(value, "https://") {
-								return fmt.Errorf("endpoint must start with http:// or https://")
-							}
-						}
-						fieldVal.SetString(value)
-					case reflect.Int:
-						var intVal int
-						_, err := fmt.Sscanf(value, "%d", &intVal)
-						if err != nil {
-							return fmt.Errorf("invalid integer value for %s: %v", key, err)
-						}
-						fieldVal.SetInt(int64(intVal))
-					default:
-						return fmt.Errorf("unsupported field type for %s", key)
-					}
-					found = true
-					break
-				}
-			}
-		}
-
-		if !found {
+		fieldVal, ok := configField(cfg, key)
+		if !ok {
 			return fmt.Errorf("unknown config key: %s", key)
 		}
 
-		data, err := json.MarshalIndent(cfg, "", "  ")
-		if err != nil {
-			return fmt.Errorf("failed to serialize config: %w", err)
-		}
+		// Store old value for display
+		oldValue, _ := plainConfigValue(fieldVal)
 
-		if cfgPath == "" {
-			home, err := os.UserHomeDir()
-			if err != nil {
-				return fmt.Errorf("failed to get home dir: %w", err)
-			}
-			cfgPath = filepath.Join(home, ".config", "oneliner", "config.json")
+		if err := setConfigValue(fieldVal, key, value); err != nil {
+			return err
 		}
 
-		if err := os.WriteFile(cfgPath, data, 0600); err != nil {
+		if err := config.Save("", cfg); err != nil {
 			return fmt.Errorf("failed to write config: %w", err)
 		}
 
@@ -129,6 +80,57 @@ var setCmd = &cobra.Command{
 	},
 }
 
+// setConfigValue validates value for key and stores it in fieldVal. Lists and
+// maps are not supported; they have dedicated commands or are edited by hand.
+func setConfigValue(fieldVal reflect.Value, key, value string) error {
+	switch fieldVal.Kind() {
+	case reflect.String:
+		if (key == "local_llm_endpoint" || key == "openai_base_url") && value != "" {
+			if !strings.HasPrefix(value, "http://") && !strings.HasPrefix(value, "https://") {
+				return fmt.Errorf("endpoint must start with http:// or https://")
+			}
+		}
+		if key == "risk_display" && value != "full" && value != "compact" {
+			return fmt.Errorf("risk_display must be full or compact")
+		}
+		if key == "max_auto_risk_level" && value != "" && !slices.Contains(config.RiskPatternLevels, strings.ToLower(value)) {
+			return fmt.Errorf("max_auto_risk_level must be empty or one of %s", strings.Join(config.RiskPatternLevels, ", "))
+		}
+		if key == "llm_api" && !slices.Contains(llm.Providers, value) {
+			return fmt.Errorf("unknown llm_api %q; valid options: %s", value, strings.Join(llm.Providers, ", "))
+		}
+		if key == "model" && strings.TrimSpace(value) == "" {
+			return fmt.Errorf("model must not be empty")
+		}
+		fieldVal.SetString(value)
+	case reflect.Int:
+		var intVal int
+		_, err := fmt.Sscanf(value, "%d", &intVal)
+		if err != nil {
+			return fmt.Errorf("invalid integer value for %s: %v", key, err)
+		}
+		fieldVal.SetInt(int64(intVal))
+	case reflect.Bool:
+		boolVal, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean value for %s: %v", key, err)
+		}
+		fieldVal.SetBool(boolVal)
+	case reflect.Ptr:
+		if fieldVal.Type().Elem().Kind() != reflect.Float64 {
+			return fmt.Errorf("unsupported field type for %s", key)
+		}
+		floatVal, err := parseSamplingValue(key, value)
+		if err != nil {
+			return err
+		}
+		fieldVal.Set(reflect.ValueOf(floatVal))
+	default:
+		return fmt.Errorf("unsupported field type for %s", key)
+	}
+	return nil
+}
+
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List current configuration values",
@@ -162,48 +164,7 @@ var listCmd = &cobra.Command{
 			jsonTag := field.Tag.Get("json")
 			fieldVal := v.Field(i)
 
-			value := ""
-			typeStr := ""
-			switch fieldVal.Kind() {
-			case reflect.String:
-				value = fieldVal.String()
-				if value == "" {
-					value = hintStyle.Render("<not set>")
-				} else if jsonTag == "api_key" && value != "" {
-					// Mask API key
-					if len(value) > 8 {
-						value = value[:4] + "..." + value[len(value)-4:]
-					} else {
-						value = "***"
-					}
-					value = valueStyle.Render(value)
-				} else {
-					value = valueStyle.Render(value)
-				}
-				typeStr = "string"
-			case reflect.Int:
-				value = valueStyle.Render(strconv.Itoa(int(fieldVal.Int())))
-				typeStr = "int"
-
-			case reflect.Slice:
-				// handle []string gracefully
-				if fieldVal.Len() == 0 {
-					value = hintStyle.Render("[]")
-				} else {
-					elems := make([]string, fieldVal.Len())
-					for j := 0; j < fieldVal.Len(); j++ {
-						elem := fieldVal.Index(j)
-						elems[j] = fmt.Sprintf("%v", elem.Interface())
-					}
-					joined := "[" + strings.Join(elems, ", ") + "]"
-					value = valueStyle.Render(joined)
-				}
-				typeStr = "array[string]"
-
-			default:
-				value = hintStyle.Render("<unsupported>")
-				typeStr = fieldVal.Kind().String()
-			}
+			value, typeStr := formatConfigValue(cfg, jsonTag, fieldVal)
 
 			// Format: key (type) : value
 			padding := strings.Repeat(" ", maxKeyLen-len(jsonTag))
@@ -222,20 +183,153 @@ var listCmd = &cobra.Command{
 	},
 }
 
+var revealFlag bool
+
+var getCmd = &cobra.Command{
+	Use:          "get [key]",
+	Short:        "Print a single configuration value, unstyled, for scripts",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load("")
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		key := args[0]
+		fieldVal, ok := configField(cfg, key)
+		if !ok {
+			return fmt.Errorf("unknown config key: %s", key)
+		}
+
+		value, err := plainConfigValue(fieldVal)
+		if err != nil {
+			return err
+		}
+		if key == "api_key" {
+			if value == "" {
+				value, _ = config.APIKeyFromEnv(cfg.LLMAPI)
+			}
+			if value != "" && !revealFlag {
+				value = maskSecret(value)
+			}
+		}
+
+		fmt.Println(value)
+		return nil
+	},
+}
+
+// configField returns the settable field of cfg whose json tag is key.
+func configField(cfg *config.Config, key string) (reflect.Value, bool) {
+	v := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).Tag.Get("json") == key {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// plainConfigValue formats a config field for scripts: scalars as-is, an
+// unset float as an empty line, and lists and maps as JSON.
+func plainConfigValue(fieldVal reflect.Value) (string, error) {
+	switch fieldVal.Kind() {
+	case reflect.String:
+		return fieldVal.String(), nil
+	case reflect.Int:
+		return strconv.Itoa(int(fieldVal.Int())), nil
+	case reflect.Bool:
+		return strconv.FormatBool(fieldVal.Bool()), nil
+	case reflect.Ptr:
+		if fieldVal.IsNil() {
+			return "", nil
+		}
+		return strconv.FormatFloat(fieldVal.Elem().Float(), 'g', -1, 64), nil
+	default:
+		data, err := json.Marshal(fieldVal.Interface())
+		if err != nil {
+			return "", fmt.Errorf("failed to encode value: %w", err)
+		}
+		return string(data), nil
+	}
+}
+
+var unsetCmd = &cobra.Command{
+	Use:   "unset [key]",
+	Short: "Reset a configuration value to its default",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key := args[0]
+
+		cfg, err := config.Load("")
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		fieldVal, ok := configField(cfg, key)
+		if !ok {
+			return fmt.Errorf("unknown config key: %s", key)
+		}
+		defaultVal, _ := configField(config.Default(), key)
+
+		oldValue, _ := formatConfigValue(cfg, key, fieldVal)
+		fieldVal.Set(defaultVal)
+		newValue, _ := formatConfigValue(cfg, key, fieldVal)
+
+		if err := config.Save("", cfg); err != nil {
+			return fmt.Errorf("failed to write config: %w", err)
+		}
+
+		fmt.Println()
+		fmt.Print(successStyle.Render("  ✓ Reset to default"))
+		fmt.Println()
+		fmt.Println()
+		fmt.Printf("  %s\n", keyStyle.Render(key))
+		fmt.Printf("    %s → %s\n", oldValue, newValue)
+		fmt.Println()
+
+		return nil
+	},
+}
+
+var resetYesFlag bool
+
+var resetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Rewrite the whole config file with the defaults",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !resetYesFlag {
+			fmt.Print(warningStyle.Render("  ⚠ Reset every setting, including api_key, to its default? [y/N] "))
+			answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			answer = strings.ToLower(strings.TrimSpace(answer))
+			if answer != "y" && answer != "yes" {
+				fmt.Println(dimStyle.Render("  • config left unchanged"))
+				return nil
+			}
+		}
+
+		if err := config.Save("", config.Default()); err != nil {
+			return fmt.Errorf("failed to write config: %w", err)
+		}
+
+		fmt.Println()
+		fmt.Print(successStyle.Render("  ✓ Configuration reset to defaults"))
+		fmt.Println()
+		fmt.Println()
+		return nil
+	},
+}
+
 var openCmd = &cobra.Command{
 	Use:   "open",
 	Short: "Open the default config in your editor",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		cfgPath := ""
-		if _, err := config.Load(cfgPath); err != nil {
+		if _, err := config.Load(""); err != nil {
 			return fmt.Errorf("failed to ensure config exists: %w", err)
 		}
-
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("failed to get home dir: %w", err)
-		}
-		cfgPath = filepath.Join(home, ".config", "oneliner", "config.json")
+		cfgPath := config.DefaultPath()
 
 		editor := os.Getenv("EDITOR")
 		if editor != "" {
@@ -273,9 +367,144 @@ var openCmd = &cobra.Command{
 	},
 }
 
+// formatConfigValue renders the value of the config field jsonTag for display,
+// masking secrets, together with a short name for its type.
+func formatConfigValue(cfg *config.Config, jsonTag string, fieldVal reflect.Value) (value, typeStr string) {
+	switch fieldVal.Kind() {
+	case reflect.String:
+		value = fieldVal.String()
+		if value == "" && jsonTag == "api_key" {
+			if key, source := config.APIKeyFromEnv(cfg.LLMAPI); key != "" {
+				value = valueStyle.Render(maskSecret(key)) + hintStyle.Render(" (from $"+source+")")
+			} else {
+				value = hintStyle.Render("<not set>")
+			}
+		} else if value == "" {
+			value = hintStyle.Render("<not set>")
+		} else if jsonTag == "api_key" && value != "" {
+			value = valueStyle.Render(maskSecret(value))
+		} else {
+			value = valueStyle.Render(value)
+		}
+		typeStr = "string"
+	case reflect.Int:
+		value = valueStyle.Render(strconv.Itoa(int(fieldVal.Int())))
+		typeStr = "int"
+	case reflect.Bool:
+		value = valueStyle.Render(strconv.FormatBool(fieldVal.Bool()))
+		typeStr = "bool"
+	case reflect.Ptr:
+		if fieldVal.IsNil() {
+			value = hintStyle.Render("<not set>")
+		} else {
+			value = valueStyle.Render(strconv.FormatFloat(fieldVal.Elem().Float(), 'g', -1, 64))
+		}
+		typeStr = "float"
+
+	case reflect.Slice:
+		// handle []string gracefully
+		if fieldVal.Len() == 0 {
+			value = hintStyle.Render("[]")
+		} else {
+			elems := make([]string, fieldVal.Len())
+			for j := 0; j < fieldVal.Len(); j++ {
+				elem := fieldVal.Index(j)
+				if elem.Kind() == reflect.Struct {
+					// objects such as few-shot examples read best as JSON
+					data, _ := json.Marshal(elem.Interface())
+					elems[j] = string(data)
+				} else {
+					elems[j] = fmt.Sprintf("%v", elem.Interface())
+				}
+			}
+			joined := "[" + strings.Join(elems, ", ") + "]"
+			value = valueStyle.Render(joined)
+		}
+		typeStr = "array[string]"
+		if fieldVal.Type().Elem().Kind() == reflect.Struct {
+			typeStr = "array[object]"
+		}
+
+	case reflect.Map:
+		if fieldVal.Len() == 0 {
+			value = hintStyle.Render("{}")
+		} else {
+			keys := make([]string, 0, fieldVal.Len())
+			for _, k := range fieldVal.MapKeys() {
+				keys = append(keys, k.String())
+			}
+			sort.Strings(keys)
+			elems := make([]string, len(keys))
+			for j, k := range keys {
+				v := fmt.Sprintf("%v", fieldVal.MapIndex(reflect.ValueOf(k)).Interface())
+				if looksSecret(k) {
+					v = maskSecret(v)
+				}
+				elems[j] = fmt.Sprintf("%s: %s", k, v)
+			}
+			value = valueStyle.Render("{" + strings.Join(elems, ", ") + "}")
+		}
+		typeStr = "map[string]string"
+
+	default:
+		value = hintStyle.Render("<unsupported>")
+		typeStr = fieldVal.Kind().String()
+	}
+
+	return value, typeStr
+}
+
+// maskSecret hides all but the edges of a secret value.
+func maskSecret(value string) string {
+	if len(value) > 8 {
+		return value[:4] + "..." + value[len(value)-4:]
+	}
+	return "***"
+}
+
+// looksSecret reports whether a map key (e.g. a header name) probably holds a credential.
+func looksSecret(name string) bool {
+	name = strings.ToLower(name)
+	for _, marker := range []string{"auth", "token", "key", "secret", "password", "cookie"} {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// samplingRanges are the accepted bounds for the optional sampling settings.
+var samplingRanges = map[string][2]float64{
+	"temperature": {0, 2},
+	"top_p":       {0, 1},
+}
+
+// parseSamplingValue parses a float setting such as temperature. An empty
+// value or "unset" clears it so the provider default applies again.
+func parseSamplingValue(key, value string) (*float64, error) {
+	if value == "" || value == "unset" {
+		return nil, nil
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number for %s: %v", key, err)
+	}
+	if r, ok := samplingRanges[key]; ok && (f < r[0] || f > r[1]) {
+		return nil, fmt.Errorf("%s must be between %g and %g", key, r[0], r[1])
+	}
+	return &f, nil
+}
+
 func init() {
 	rootCmd.AddCommand(configCmd)
 	configCmd.AddCommand(setCmd)
 	configCmd.AddCommand(listCmd)
+	configCmd.AddCommand(getCmd)
+	configCmd.AddCommand(unsetCmd)
+	configCmd.AddCommand(resetCmd)
 	configCmd.AddCommand(openCmd)
+
+	getCmd.Flags().BoolVar(&revealFlag, "reveal", false, "Print api_key in full instead of masked")
+	resetCmd.Flags().BoolVarP(&resetYesFlag, "yes", "y", false, "Reset without asking for confirmation")
 }
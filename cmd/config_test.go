@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dorochadev/oneliner/config"
+	"github.com/spf13/cobra"
+)
+
+// TestCommandTree guards against two files registering the same command,
+// as the old configCmd.go and config.go once did.
+func TestCommandTree(t *testing.T) {
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		seen := map[string]bool{}
+		for _, sub := range cmd.Commands() {
+			if seen[sub.Name()] {
+				t.Errorf("%q is registered twice under %q", sub.Name(), cmd.CommandPath())
+			}
+			seen[sub.Name()] = true
+			walk(sub)
+		}
+	}
+	walk(rootCmd)
+
+	found, _, err := rootCmd.Find([]string{"config"})
+	if err != nil || found != configCmd {
+		t.Fatalf("rootCmd.Find(config) = %v, %v; want configCmd", found, err)
+	}
+	for _, name := range []string{"set", "list", "get", "unset", "reset", "open"} {
+		if sub, _, err := configCmd.Find([]string{name}); err != nil || sub.Name() != name {
+			t.Errorf("config %s is not registered: %v", name, err)
+		}
+	}
+}
+
+func TestFormatConfigValueMasksAPIKey(t *testing.T) {
+	cfg := config.Default()
+	cfg.APIKey = "sk-abcdefghijklmnop"
+
+	field, _ := reflect.TypeOf(cfg).Elem().FieldByName("APIKey")
+	value, typeStr := formatConfigValue(cfg, field.Tag.Get("json"), reflect.ValueOf(cfg).Elem().FieldByName("APIKey"))
+	if strings.Contains(value, cfg.APIKey) || !strings.Contains(value, "sk-a...mnop") {
+		t.Errorf("api_key listed as %q, want it masked as sk-a...mnop", value)
+	}
+	if typeStr != "string" {
+		t.Errorf("api_key type = %q, want string", typeStr)
+	}
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dorochadev/oneliner/internal/executor"
+	"github.com/spf13/cobra"
+)
+
+var consentCmd = &cobra.Command{
+	Use:   "consent",
+	Short: "Manage the first-run consent for --run",
+}
+
+var consentStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether first-run consent has been granted",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		consentFile, err := executor.ConsentPath()
+		if err != nil {
+			return err
+		}
+
+		info, err := os.Stat(consentFile)
+		if os.IsNotExist(err) {
+			fmt.Println()
+			fmt.Println(hintStyle.Render("  Consent not granted"))
+			fmt.Println(hintStyle.Render("  • you will be asked to type 'i understand' on the next --run"))
+			fmt.Println()
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read consent file: %w", err)
+		}
+
+		fmt.Println()
+		fmt.Println(successStyle.Render("  ✓ Consent granted"))
+		fmt.Printf("    %s %s\n", hintStyle.Render("when:"), valueStyle.Render(info.ModTime().Format("2006-01-02 15:04:05")))
+		fmt.Printf("    %s %s\n", hintStyle.Render("file:"), valueStyle.Render(consentFile))
+		fmt.Println()
+		return nil
+	},
+}
+
+var consentResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Revoke consent so the first-run warning is shown again",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		consentFile, err := executor.ConsentPath()
+		if err != nil {
+			return err
+		}
+
+		if _, err := os.Stat(consentFile); os.IsNotExist(err) {
+			fmt.Println("Consent is already reset")
+			return nil
+		}
+
+		if err := os.Remove(consentFile); err != nil {
+			return fmt.Errorf("failed to reset consent: %w", err)
+		}
+
+		fmt.Println("✓ Consent reset, the warning will be shown on the next --run")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(consentCmd)
+	consentCmd.AddCommand(consentStatusCmd)
+	consentCmd.AddCommand(consentResetCmd)
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"bufio"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// osReleasePaths are checked in order, as described in os-release(5).
+var osReleasePaths = []string{"/etc/os-release", "/usr/lib/os-release"}
+
+// linuxDistro returns the distribution ID and version from os-release, e.g.
+// "ubuntu 22.04", or "" on other systems or when the file is missing.
+func linuxDistro() string {
+	if runtime.GOOS != "linux" {
+		return ""
+	}
+
+	for _, path := range osReleasePaths {
+		file, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		fields := parseOSRelease(file)
+		file.Close()
+
+		return strings.TrimSpace(fields["ID"] + " " + fields["VERSION_ID"])
+	}
+	return ""
+}
+
+// parseOSRelease reads the KEY=value lines of an os-release file, unquoting values.
+func parseOSRelease(file *os.File) map[string]string {
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok || strings.HasPrefix(key, "#") {
+			continue
+		}
+		fields[key] = strings.Trim(value, `"'`)
+	}
+	return fields
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dorochadev/oneliner/config"
+	"github.com/dorochadev/oneliner/internal/llm"
+	"github.com/spf13/cobra"
+)
+
+// doctorPrompt is the canned request sent to check the provider end to end.
+const doctorPrompt = "Output only this shell command and nothing else: echo hello"
+
+const doctorTimeout = 20 * time.Second
+
+var statusCodeRegex = regexp.MustCompile(`status (\d{3})`)
+
+var doctorCmd = &cobra.Command{
+	Use:          "doctor",
+	Short:        "Check that the configured provider is reachable and accepts your key and model",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		fmt.Println()
+		fmt.Println(headerStyle.Render("  Doctor"))
+		fmt.Println()
+
+		d := &doctor{}
+		d.pass("config loaded", fmt.Sprintf("%s • %s", cfg.LLMAPI, cfg.Model))
+
+		var ok bool
+		if cfg.LLMAPI == "local" {
+			ok = d.checkLocalEndpoint(cfg.LocalLLMEndpoint)
+		} else {
+			ok = d.checkAPIKey(cfg)
+		}
+
+		if ok {
+			d.checkRequest(cfg)
+		} else {
+			d.skip("test request", "skipped until the problem above is fixed")
+		}
+
+		fmt.Println()
+		if d.failed > 0 {
+			return fmt.Errorf("doctor found %d problem(s)", d.failed)
+		}
+		fmt.Println(successStyle.Render("  ✓ Everything looks good"))
+		fmt.Println()
+		return nil
+	},
+}
+
+// doctor prints one checklist line per check and counts the failures.
+type doctor struct {
+	failed int
+}
+
+func (d *doctor) pass(name, detail string) {
+	d.line(successStyle.Render("✓"), name, detail)
+}
+
+func (d *doctor) fail(name, detail string) {
+	d.failed++
+	d.line(cancelStyle.Render("✗"), name, detail)
+}
+
+func (d *doctor) skip(name, detail string) {
+	d.line(hintStyle.Render("•"), hintStyle.Render(name), detail)
+}
+
+func (d *doctor) line(marker, name, detail string) {
+	fmt.Printf("  %s %s\n", marker, name)
+	if detail != "" {
+		for _, l := range strings.Split(strings.TrimSpace(detail), "\n") {
+			fmt.Println(hintStyle.Render("      " + l))
+		}
+	}
+}
+
+func (d *doctor) checkAPIKey(cfg *config.Config) bool {
+	if strings.TrimSpace(cfg.APIKey) != "" {
+		d.pass("api key set", maskSecret(cfg.APIKey))
+		return true
+	}
+	if key, source := config.APIKeyFromEnv(cfg.LLMAPI); key != "" {
+		d.pass("api key set", maskSecret(key)+" (from $"+source+")")
+		return true
+	}
+	d.fail("api key set", "no api_key in config and none in the environment • run: oneliner setup")
+	return false
+}
+
+// checkLocalEndpoint dials the local endpoint directly so a stopped server is
+// reported as such rather than as a failed request.
+func (d *doctor) checkLocalEndpoint(endpoint string) bool {
+	if err := llm.CheckReachable(context.Background(), endpoint); err != nil {
+		d.fail("endpoint reachable", err.Error())
+		return false
+	}
+	d.pass("endpoint reachable", endpoint)
+	d.pass("payload format", llm.LocalFormat(endpoint))
+	return true
+}
+
+// checkRequest sends doctorPrompt and reports reachability, authentication and
+// model validity separately, based on how far the request got.
+func (d *doctor) checkRequest(cfg *config.Config) {
+	probe := *cfg
+	probe.MaxRetries = -1
+	probe.Stream = false
+
+	llmInstance, err := llm.New(&probe)
+	if err != nil {
+		d.fail("provider initialized", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+	defer cancel()
+	_, err = llmInstance.GenerateCommand(ctx, doctorPrompt)
+
+	if err == nil {
+		d.pass("api reachable", "")
+		d.pass("authenticated", "")
+		d.pass("model accepted", cfg.Model)
+		return
+	}
+
+	status := 0
+	if m := statusCodeRegex.FindStringSubmatch(err.Error()); m != nil {
+		status, _ = strconv.Atoi(m[1])
+	}
+
+	switch {
+	case status == 0:
+		detail := err.Error()
+		if errors.Is(err, context.DeadlineExceeded) {
+			detail = fmt.Sprintf("no answer within %s", doctorTimeout)
+		}
+		d.fail("api reachable", detail)
+		d.skip("authenticated", "not checked")
+		d.skip("model accepted", "not checked")
+	case status == 401 || status == 403:
+		d.pass("api reachable", "")
+		d.fail("authenticated", fmt.Sprintf("status %d • check api_key", status))
+		d.skip("model accepted", "not checked")
+	case status == 404 || (status == 400 && strings.Contains(strings.ToLower(err.Error()), "model")):
+		d.pass("api reachable", "")
+		d.pass("authenticated", "")
+		d.fail("model accepted", fmt.Sprintf("%s was rejected (status %d) • check model", cfg.Model, status))
+	default:
+		d.pass("api reachable", "")
+		d.fail("request succeeded", err.Error())
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dorochadev/oneliner/internal/prompt"
+	"github.com/spf13/cobra"
+)
+
+var explainCommandCmd = &cobra.Command{
+	Use:   "explain-command [command]",
+	Short: "Explain an existing shell command instead of generating one",
+	Long: "Explain what an existing shell command does, e.g. one pasted from the web. " +
+		"Quote the command, or pass - (or nothing) to read it from stdin.",
+	Example: `  oneliner explain-command 'find . -name "*.log" -mtime +7 -delete'
+  oneliner explain-command -b 'tar -xzvf archive.tar.gz -C /tmp'
+  pbpaste | oneliner explain-command`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		defer func() {
+			if errors.Is(err, errCancelled) {
+				cmd.SilenceErrors = true
+				cmd.SilenceUsage = true
+			}
+		}()
+
+		command, err := commandFromArgs(args)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		s := &session{cfg: cfg, ctx: gatherContext(nil, cfg)}
+		s.warnDeprecatedModel()
+		response, err := s.complete(prompt.BuildExplanation(s.ctx, cfg, command, breakdownFlag))
+		if err != nil {
+			return fmt.Errorf("failed to explain command: %w", err)
+		}
+
+		_, explanation, breakdown := parseResponse(response)
+		if explanation == "" && breakdown == "" {
+			// the model ignored the format; show its answer rather than nothing
+			explanation = strings.TrimSpace(response)
+		}
+
+		explainFlag = true
+		displayCommand(command, explanation, breakdown)
+		s.printUsage()
+		return nil
+	},
+}
+
+// commandFromArgs joins args into the command, reading it from stdin when
+// there are no args or the only one is "-".
+func commandFromArgs(args []string) (string, error) {
+	command := strings.Join(args, " ")
+	if len(args) == 0 || command == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read command from stdin: %w", err)
+		}
+		command = string(data)
+	}
+
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return "", fmt.Errorf("no command given")
+	}
+	return command, nil
+}
+
+func init() {
+	rootCmd.AddCommand(explainCommandCmd)
+
+	flags := explainCommandCmd.Flags()
+	flags.BoolVarP(&breakdownFlag, "breakdown", "b", false, "Include a detailed breakdown of each stage of the command")
+	flags.BoolVar(&prettyFlag, "pretty", false, "Render the breakdown as an indented, numbered tree")
+	flags.BoolVar(&usageFlag, "usage", false, "Show the number of tokens the request used")
+	flags.StringArrayVar(&configPaths, "config", nil, "Specify alternative config file, or - to read JSON from stdin (repeatable)")
+	flags.StringVar(&profileName, "profile", "", "Use the named config profile")
+}
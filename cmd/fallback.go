@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/dorochadev/oneliner/config"
+	"github.com/dorochadev/oneliner/internal/llm"
+)
+
+// providerChain returns the configs to try in order: the configured provider
+// first, then one per fallback_providers entry. Entries are "provider" or
+// "provider:model"; without a model the provider's default model is used.
+// A fallback only shares the api_key with the primary when it is the same
+// provider, otherwise its key comes from that provider's own variables.
+func providerChain(cfg *config.Config) []*config.Config {
+	chain := []*config.Config{cfg}
+	seen := map[string]bool{cfg.LLMAPI + ":" + cfg.Model: true}
+
+	for _, entry := range cfg.FallbackProviders {
+		name, model, _ := strings.Cut(strings.TrimSpace(entry), ":")
+		name = strings.ToLower(strings.TrimSpace(name))
+		model = strings.TrimSpace(model)
+		if name == "" {
+			continue
+		}
+		if model == "" {
+			if name == cfg.LLMAPI {
+				model = cfg.Model
+			} else if suggestions := modelSuggestions[name]; len(suggestions) > 0 {
+				model = suggestions[0]
+			}
+		}
+		if seen[name+":"+model] {
+			continue
+		}
+		seen[name+":"+model] = true
+
+		fallback := *cfg
+		fallback.LLMAPI = name
+		fallback.Model = model
+		if name != cfg.LLMAPI {
+			fallback.APIKey, _ = otherProviderKey(name)
+		}
+		chain = append(chain, &fallback)
+	}
+
+	sortByPriority(chain[1:], cfg.ProviderPriority)
+	return chain
+}
+
+// otherProviderKey returns the API key for a provider other than llm_api.
+// api_key and ONELINER_API_KEY belong to llm_api, so only the provider's own
+// variables count; without one only local, which needs no key, can be used.
+func otherProviderKey(provider string) (string, error) {
+	key, _ := config.ProviderAPIKeyFromEnv(provider)
+	if key == "" && provider != "local" {
+		return "", fmt.Errorf("no API key for %s: set its provider-specific variable (api_key and ONELINER_API_KEY only apply to llm_api)", provider)
+	}
+	return key, nil
+}
+
+// sortByPriority orders fallbacks by their provider_priority cost, cheapest
+// first. Providers without a cost keep their config order after the others.
+func sortByPriority(fallbacks []*config.Config, priority map[string]int) {
+	if len(priority) == 0 {
+		return
+	}
+	sort.SliceStable(fallbacks, func(i, j int) bool {
+		ci, iok := priority[fallbacks[i].LLMAPI]
+		cj, jok := priority[fallbacks[j].LLMAPI]
+		if iok != jok {
+			return iok
+		}
+		return ci < cj
+	})
+}
+
+// complete sends promptText to the configured provider and, if that fails, to
+// each fallback provider in turn. Cancellation stops the chain immediately.
+func (s *session) complete(promptText string) (string, error) {
+	chain := providerChain(s.cfg)
+
+	var errs []error
+	for i, cfg := range chain {
+		if i > 0 {
+			fmt.Fprintln(os.Stderr, dimStyle.Render(fmt.Sprintf("  ⚠ %s failed • trying %s", chain[i-1].LLMAPI, cfg.LLMAPI)))
+		}
+
+		// left blank, llm.New would fall back to ONELINER_API_KEY
+		if cfg.LLMAPI != s.cfg.LLMAPI && cfg.APIKey == "" {
+			if _, err := otherProviderKey(cfg.LLMAPI); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+
+		llmInstance, err := llm.New(cfg)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to initialize LLM: %w", err))
+			continue
+		}
+
+		response, err := generateWithSpinner(llmInstance, promptText)
+		if errors.Is(err, errCancelled) {
+			return "", err
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		s.recordUsage(llmInstance)
+		if i > 0 {
+			fmt.Fprintln(os.Stderr, dimStyle.Render(fmt.Sprintf("  • answered by %s (%s) • fallback", cfg.LLMAPI, cfg.Model)))
+		}
+		return response, nil
+	}
+
+	if len(errs) == 1 {
+		return "", errs[0]
+	}
+	for i, err := range errs {
+		errs[i] = fmt.Errorf("%s: %w", chain[i].LLMAPI, err)
+	}
+	return "", fmt.Errorf("all providers failed:\n%w", errors.Join(errs...))
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/dorochadev/oneliner/config"
+)
+
+func TestProviderChainPriority(t *testing.T) {
+	tests := []struct {
+		name      string
+		fallbacks []string
+		priority  map[string]int
+		want      []string
+	}{
+		{
+			"config order without priorities",
+			[]string{"mistral", "gemini", "local"},
+			nil,
+			[]string{"openai:gpt-4o", "mistral:mistral-large-latest", "gemini:gemini-1.5-pro", "local:llama3"},
+		},
+		{
+			"cheapest first",
+			[]string{"mistral", "gemini", "local"},
+			map[string]int{"mistral": 3, "gemini": 2, "local": 1},
+			[]string{"openai:gpt-4o", "local:llama3", "gemini:gemini-1.5-pro", "mistral:mistral-large-latest"},
+		},
+		{
+			"primary stays first even when it costs most",
+			[]string{"local"},
+			map[string]int{"openai": 10, "local": 0},
+			[]string{"openai:gpt-4o", "local:llama3"},
+		},
+		{
+			"unpriced keep config order after priced",
+			[]string{"cohere", "mistral", "gemini", "local"},
+			map[string]int{"local": 5},
+			[]string{"openai:gpt-4o", "local:llama3", "cohere:command-r-plus", "mistral:mistral-large-latest", "gemini:gemini-1.5-pro"},
+		},
+		{
+			"equal cost keeps config order",
+			[]string{"gemini:gemini-1.5-flash", "mistral", "gemini"},
+			map[string]int{"gemini": 1, "mistral": 1},
+			[]string{"openai:gpt-4o", "gemini:gemini-1.5-flash", "mistral:mistral-large-latest", "gemini:gemini-1.5-pro"},
+		},
+		{
+			"same provider, other model",
+			[]string{"openai:gpt-4o-mini", " OpenAI ", "local:codellama"},
+			map[string]int{"local": 1, "openai": 2},
+			[]string{"openai:gpt-4o", "local:codellama", "openai:gpt-4o-mini"},
+		},
+		{
+			"blank entries",
+			[]string{"", " ", ":x", "local"},
+			nil,
+			[]string{"openai:gpt-4o", "local:llama3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.LLMAPI = "openai"
+			cfg.Model = "gpt-4o"
+			cfg.APIKey = "sk-primary"
+			cfg.FallbackProviders = tt.fallbacks
+			cfg.ProviderPriority = tt.priority
+
+			var got []string
+			for _, c := range providerChain(cfg) {
+				got = append(got, c.LLMAPI+":"+c.Model)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("providerChain = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProviderChainKeys(t *testing.T) {
+	// ONELINER_API_KEY belongs to the primary and must not reach claude
+	t.Setenv("ONELINER_API_KEY", "sk-primary")
+	t.Setenv("ANTHROPIC_API_KEY", "sk-ant")
+	t.Setenv("MISTRAL_API_KEY", "")
+
+	cfg := config.Default()
+	cfg.LLMAPI = "openai"
+	cfg.Model = "gpt-4o"
+	cfg.APIKey = "sk-primary"
+	cfg.FallbackProviders = []string{"claude", "openai:gpt-4o-mini", "mistral", "local"}
+
+	chain := providerChain(cfg)
+	want := []string{"sk-primary", "sk-ant", "sk-primary", "", ""}
+	if len(chain) != len(want) {
+		t.Fatalf("chain has %d entries, want %d", len(chain), len(want))
+	}
+	for i, c := range chain {
+		if c.APIKey != want[i] {
+			t.Errorf("%s key = %q, want %q", c.LLMAPI, c.APIKey, want[i])
+		}
+	}
+	if cfg.Model != "gpt-4o" || cfg.APIKey != "sk-primary" {
+		t.Errorf("providerChain modified the primary config: %s %q", cfg.Model, cfg.APIKey)
+	}
+}
+
+func TestOtherProviderKey(t *testing.T) {
+	t.Setenv("ONELINER_API_KEY", "sk-primary")
+	t.Setenv("ANTHROPIC_API_KEY", "sk-ant")
+	t.Setenv("MISTRAL_API_KEY", "")
+
+	tests := []struct {
+		provider string
+		want     string
+		wantErr  bool
+	}{
+		{"claude", "sk-ant", false},
+		{"mistral", "", true},
+		{"local", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			got, err := otherProviderKey(tt.provider)
+			if got != tt.want || (err != nil) != tt.wantErr {
+				t.Errorf("otherProviderKey(%s) = %q, %v; want %q, error %v", tt.provider, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
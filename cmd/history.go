@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dorochadev/oneliner/internal/history"
+	"github.com/spf13/cobra"
+)
+
+var historyLimit int
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List recent queries and the commands they produced",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if historyLimit < 0 {
+			return fmt.Errorf("--limit must not be negative")
+		}
+
+		historyPath, err := getHistoryPath()
+		if err != nil {
+			return err
+		}
+
+		entries, err := history.Load(historyPath)
+		if err != nil {
+			return err
+		}
+
+		if len(entries) == 0 {
+			fmt.Println("History is empty")
+			return nil
+		}
+
+		// newest last, like a shell history, so the latest query is closest to the prompt
+		shown := entries
+		if historyLimit > 0 && len(shown) > historyLimit {
+			shown = shown[len(shown)-historyLimit:]
+		}
+
+		fmt.Printf("Showing %d of %d queries:\n\n", len(shown), len(entries))
+		for _, entry := range shown {
+			command := entry.Command
+			if len(command) > 80 {
+				command = command[:77] + "..."
+			}
+
+			fmt.Println(queryStyle.Render(entry.Query))
+			fmt.Printf("    %s\n", idStyle.Render(command))
+
+			meta := fmt.Sprintf("%s · %s", formatTimestamp(entry.Time), entry.Provider)
+			if entry.Model != "" {
+				meta += " " + entry.Model
+			}
+			if entry.Cached {
+				meta += " · cached"
+			}
+			fmt.Printf("    %s\n\n", timestampStyle.Render(meta))
+		}
+
+		fmt.Printf("Use 'oneliner history --limit 0' to show every entry\n")
+		fmt.Printf("Use 'oneliner history clear' to clear the history\n")
+		return nil
+	},
+}
+
+var historyClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Clear the query history",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		historyPath, err := getHistoryPath()
+		if err != nil {
+			return err
+		}
+
+		if _, err := os.Stat(historyPath); os.IsNotExist(err) {
+			fmt.Println("History is already empty")
+			return nil
+		}
+
+		if err := history.Clear(historyPath); err != nil {
+			return fmt.Errorf("failed to clear history: %w", err)
+		}
+
+		fmt.Println("✓ History cleared successfully")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.AddCommand(historyClearCmd)
+
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Show at most `N` of the most recent queries (0 for all)")
+}
+
+// getHistoryPath returns the history file, ONELINER_HISTORY_PATH or
+// ~/.local/share/oneliner/history.jsonl.
+func getHistoryPath() (string, error) {
+	historyPath := os.Getenv("ONELINER_HISTORY_PATH")
+	if historyPath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		historyPath = filepath.Join(home, ".local", "share", "oneliner", "history.jsonl")
+	}
+
+	absPath, err := filepath.Abs(historyPath)
+	if err != nil {
+		return "", fmt.Errorf("invalid history path: %w", err)
+	}
+	return absPath, nil
+}
+
+// recordHistory appends the query and its command to the history. Failing to
+// do so only warns: the command itself is still shown and run.
+func (s *session) recordHistory(command string, cached bool) {
+	historyPath, err := getHistoryPath()
+	if err == nil {
+		err = history.Append(historyPath, history.Entry{
+			Time:     time.Now(),
+			Query:    s.ctx.Query,
+			Command:  command,
+			Provider: s.cfg.LLMAPI,
+			Model:    s.cfg.Model,
+			Cached:   cached,
+		})
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, dimStyle.Render("  ⚠ failed to write history: "+err.Error()))
+	}
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import "regexp"
+
+// bashisms are common non-POSIX constructs, checked as a backstop for --posix.
+var bashisms = []struct {
+	pattern *regexp.Regexp
+	name    string
+}{
+	{regexp.MustCompile(`\[\[`), "[[ ]]"},
+	{regexp.MustCompile(`(^|[;&|\s])function\s+\w+`), "function keyword"},
+	{regexp.MustCompile(`\w+=\(`), "array assignment"},
+	{regexp.MustCompile(`\$\{\w+\[`), "array expansion"},
+	{regexp.MustCompile(`<<<`), "here-string <<<"},
+	{regexp.MustCompile(`\$'`), "$'...' quoting"},
+	{regexp.MustCompile(`&>`), "&> redirection"},
+	{regexp.MustCompile(`(^|[;&|\s])source\s`), "source (use .)"},
+	{regexp.MustCompile(`\{[^{}\s,]*,[^{}\s]*\}`), "brace expansion"},
+	{regexp.MustCompile(`\becho\s+-e\b`), "echo -e (use printf)"},
+	{regexp.MustCompile(`\[\s[^]]*\s==\s`), "== in [ ] (use =)"},
+}
+
+// lintBashisms returns the names of bashisms found in command.
+func lintBashisms(command string) []string {
+	var found []string
+	for _, b := range bashisms {
+		if b.pattern.MatchString(command) {
+			found = append(found, b.name)
+		}
+	}
+	return found
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/dorochadev/oneliner/config"
+	"github.com/spf13/cobra"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage named config profiles (config.<name>.json)",
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the saved config profiles",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		names, err := config.Profiles()
+		if err != nil {
+			return fmt.Errorf("failed to list profiles: %w", err)
+		}
+
+		if len(names) == 0 {
+			fmt.Println()
+			fmt.Println(hintStyle.Render("  No profiles yet • save one as config.<name>.json next to config.json"))
+			fmt.Println()
+			return nil
+		}
+
+		fmt.Println()
+		fmt.Println(headerStyle.Render("  Profiles"))
+		fmt.Println()
+		for _, name := range names {
+			path, _ := config.ProfilePath(name)
+			fmt.Printf("  %s %s\n", keyStyle.Render(name), hintStyle.Render(path))
+		}
+		fmt.Println()
+		fmt.Println(hintStyle.Render("  Use 'oneliner --profile <name> ...' once, or 'oneliner config profile use <name>' to switch"))
+		fmt.Println()
+		return nil
+	},
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use [name]",
+	Short: "Make a profile the active config by copying it over config.json",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := config.UseProfile(args[0]); err != nil {
+			return err
+		}
+
+		fmt.Println()
+		fmt.Print(successStyle.Render("  ✓ Switched to profile " + args[0]))
+		fmt.Println()
+		fmt.Println()
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(profileCmd)
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileUseCmd)
+}
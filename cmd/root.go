@@ -1,13 +1,20 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"os"
+	"os/exec"
+	"os/signal"
 	"os/user"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/atotto/clipboard"
@@ -19,7 +26,9 @@ import (
 	"github.com/dorochadev/oneliner/internal/executor"
 	"github.com/dorochadev/oneliner/internal/llm"
 	"github.com/dorochadev/oneliner/internal/prompt"
+	"github.com/muesli/termenv"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"golang.org/x/term"
 )
 
@@ -29,17 +38,47 @@ var (
 	sudoFlag         bool
 	explainFlag      bool
 	breakdownFlag    bool
-	configPath       string
+	configPaths      []string
 	clipboardFlag    bool
+	quietFlag        bool
+	annotateFlag     bool
+	prettyFlag       bool
+	posixFlag        bool
+	asciiOnlyFlag    bool
+	explainPlainFlag bool
+	usageFlag        bool
+	saveScriptPath   string
+	forceFlag        bool
+	noCacheFlag      bool
+	dryRunFlag       bool
+	noAuditFlag      bool
+	yesFlag          bool
+	rawFlag          bool
+	noColorFlag      bool
+	regenerateFlag   bool
+	editFlag         bool
+	shellFlag        string
+	cwdFlag          string
+	outputPath       string
+	profileName      string
+	batchFile        string
 	commandStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
 	explanationStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
 	breakdownStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("12"))
 	dimStyle         = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
 	cancelStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true)
 	cyanStyle        = lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Bold(true)
+	warningStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Bold(true)
 	rng              = rand.New(rand.NewSource(time.Now().UnixNano()))
 )
 
+// errCancelled is returned when the user interrupts a request with Ctrl+C.
+var errCancelled = errors.New("request cancelled")
+
+// errRefusal is returned when the model answers with prose declining the request
+// instead of a command.
+var errRefusal = errors.New("the model declined to generate a command")
+
 var loadingMessages = []string{
 	"⚙️ Generating one-liner...",
 	"🔍 Finding the simplest command...",
@@ -60,76 +99,400 @@ var rootCmd = &cobra.Command{
 	Use:   "oneliner [query]",
 	Short: "Generate shell one-liners from natural language",
 	Long:  "A CLI tool that generates shell one-liners from natural-language input using LLMs.",
-	Args:  cobra.MinimumNArgs(1),
-	RunE:  run,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if batchFile != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
+	RunE: run,
 }
 
 func init() {
-	rootCmd.Flags().BoolVarP(&executeFlag, "run", "r", false, "Run the generated command as-is")
+	cobra.OnInitialize(applyColorMode)
+	rootCmd.PersistentFlags().BoolVar(&noColorFlag, "no-color", false, "Disable colors and other styling (also set by NO_COLOR)")
+	addGenerationFlags(rootCmd.Flags())
+	rootCmd.Flags().StringVar(&batchFile, "batch", "", "Generate a command for every query line in a file")
+	rootCmd.Flags().BoolVar(&abFlag, "ab", false, "Generate two commands for the query, bypassing the cache, and pick one")
+	rootCmd.Flags().StringVar(&abModel, "ab-model", "", "Model used for the second command with --ab")
+	rootCmd.Flags().StringVar(&abTemperature, "ab-temperature", "", "Temperature used for the second command with --ab")
+}
+
+// addGenerationFlags registers the flags that control generation and execution.
+// Subcommands that feed a query into run share them with the root command.
+func addGenerationFlags(flags *pflag.FlagSet) {
+	flags.BoolVarP(&executeFlag, "run", "r", false, "Run the generated command as-is")
+	flags.BoolVar(&editFlag, "edit", false, "Edit the generated command, then run what you leave")
+	flags.BoolVarP(&yesFlag, "yes", "y", false, "Answer yes to the consent, risk and sudo prompts when running (or set ONELINER_ASSUME_YES)")
+	flags.BoolVar(&noAuditFlag, "no-audit", false, "Don't record the executed command in the audit log")
+	flags.BoolVar(&dryRunFlag, "dry-run", false, "With --run, assess and print the final command without running it")
+	flags.StringVar(&cwdFlag, "cwd", "", "Generate the command for this directory and run it there instead of the current one")
+	flags.StringVar(&shellFlag, "shell", "", "Generate for and run with this shell, e.g. zsh, fish or pwsh, instead of default_shell and sh")
 	if runtime.GOOS != "windows" {
-		rootCmd.Flags().BoolVar(&sudoFlag, "sudo", false, "Prepend 'sudo' to the generated command when executing")
+		flags.BoolVar(&sudoFlag, "sudo", false, "Prepend 'sudo' to the generated command when executing")
+	}
+	flags.BoolVarP(&explainFlag, "explain", "e", false, "Show an explanation of the generated command")
+	flags.BoolVar(&rawFlag, "raw", false, "Print only the bare command, unstyled, e.g. for $(oneliner --raw ...)")
+	flags.BoolVar(&explainPlainFlag, "explain-plain", false, "Print only the explanation as plain, unstyled text (for docs)")
+	flags.BoolVarP(&breakdownFlag, "breakdown", "b", false, "Include a detailed breakdown/pipeline of how the command works")
+	flags.BoolVarP(&interactiveFlag, "interactive", "i", false, "Interactively run the generated command")
+	flags.StringArrayVar(&configPaths, "config", nil, "Specify alternative config file, or - to read JSON from stdin (repeat to layer overrides in order)")
+	flags.StringVar(&profileName, "profile", "", "Use the named config profile (config.<name>.json) instead of the default config")
+	flags.BoolVarP(&clipboardFlag, "clipboard", "c", false, "Copy the generated command to clipboard")
+	flags.BoolVar(&asciiOnlyFlag, "ascii-only", false, "Fail if the generated command contains non-ASCII characters")
+	flags.BoolVar(&posixFlag, "posix", false, "Generate strictly POSIX sh commands (no bashisms)")
+	flags.BoolVar(&prettyFlag, "pretty", false, "Render the breakdown as an indented, numbered tree")
+	flags.BoolVar(&annotateFlag, "annotate", false, "Annotate the command with inline # comments (stripped before running)")
+	flags.BoolVarP(&quietFlag, "quiet", "q", false, "Suppress status lines such as the success/timing line when running")
+	flags.BoolVar(&usageFlag, "usage", false, "Show the number of tokens the request used")
+	flags.StringVar(&saveScriptPath, "save-script", "", "Save the command as an executable script at `path`")
+	flags.BoolVar(&forceFlag, "force", false, "Overwrite an existing file with --save-script or --output")
+	flags.StringVar(&outputPath, "output", "", "With --run, also save the command's output to `file`")
+	flags.BoolVarP(&regenerateFlag, "regenerate", "R", false, "Ask for a different command than before, ignoring and then replacing the cached one")
+	flags.BoolVar(&noCacheFlag, "no-cache", false, "Neither read nor write the cache for this query")
+	flags.IntVar(&candidatesFlag, "candidates", 1, "Generate `N` alternative commands and pick one")
+}
+
+// applyColorMode turns off all styling for --no-color or a non-empty NO_COLOR
+// (https://no-color.org). Output that is not a terminal is already unstyled.
+// Every style in cmd and internal shares lipgloss's default renderer, so
+// setting its profile covers them all.
+func applyColorMode() {
+	if noColorFlag || os.Getenv("NO_COLOR") != "" {
+		lipgloss.SetColorProfile(termenv.Ascii)
 	}
-	rootCmd.Flags().BoolVarP(&explainFlag, "explain", "e", false, "Show an explanation of the generated command")
-	rootCmd.Flags().BoolVarP(&breakdownFlag, "breakdown", "b", false, "Include a detailed breakdown/pipeline of how the command works")
-	rootCmd.Flags().BoolVarP(&interactiveFlag, "interactive", "i", false, "Interactively run the generated command")
-	rootCmd.Flags().StringVar(&configPath, "config", "", "Specify alternative config file")
-	rootCmd.Flags().BoolVarP(&clipboardFlag, "clipboard", "c", false, "Copy the generated command to clipboard")
 }
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
+		if errors.Is(err, errCancelled) {
+			fmt.Print("\r\033[K")
+			fmt.Print(cancelStyle.Render("  ✗ CANCELLED"))
+			fmt.Print(" ")
+			fmt.Println(dimStyle.Render("• request aborted"))
+			os.Exit(130)
+		}
+		if errors.Is(err, executor.ErrInterrupted) {
+			// runCommand has already reported the interruption
+			os.Exit(130)
+		}
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
 
-func run(cmd *cobra.Command, args []string) error {
+func run(cmd *cobra.Command, args []string) (err error) {
+	defer func() {
+		// Execute reports these itself; skip cobra's error and usage dump, which
+		// would bury the hint to pass --yes when there is no terminal
+		if errors.Is(err, errCancelled) || errors.Is(err, executor.ErrInterrupted) || errors.Is(err, executor.ErrNoTerminal) || errors.Is(err, executor.ErrRiskBlocked) {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+		}
+	}()
+
+	if explainPlainFlag {
+		explainFlag = true
+	}
+	if candidatesFlag < 1 || candidatesFlag > maxCandidates {
+		return fmt.Errorf("--candidates must be between 1 and %d", maxCandidates)
+	}
+	if dryRunFlag && !executeFlag && !interactiveFlag && !editFlag {
+		return fmt.Errorf("--dry-run only applies together with --run, --edit or --interactive")
+	}
+	if editFlag && interactiveFlag {
+		return fmt.Errorf("--edit cannot be combined with --interactive, which has its own edit key")
+	}
+	if outputPath != "" {
+		if !executeFlag && !interactiveFlag && !editFlag {
+			return fmt.Errorf("--output only applies together with --run, --edit or --interactive")
+		}
+		if _, err := os.Stat(outputPath); err == nil && !forceFlag {
+			return fmt.Errorf("%s already exists (use --force to overwrite)", outputPath)
+		}
+		// relative to where oneliner was started, not --cwd
+		if abs, err := filepath.Abs(outputPath); err == nil {
+			outputPath = abs
+		}
+	}
+	if err := checkCwdFlag(); err != nil {
+		return err
+	}
+	if shellFlag != "" && (executeFlag || editFlag) {
+		// check before generating; without --run the shell need not be installed here
+		if _, err := exec.LookPath(shellFlag); err != nil {
+			return fmt.Errorf("--shell %s not found, so the command could not be run", shellFlag)
+		}
+	}
+
+	if batchFile != "" {
+		return runBatch(batchFile)
+	}
+
 	// load configuration
-	cfg, err := config.Load(configPath)
+	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
+	if rawFlag {
+		// streamed tokens would land in the output next to the command
+		cfg.Stream = false
+	}
+	applyShellFlag(cfg)
+
+	if abFlag {
+		return runAB(cfg, args)
+	}
+
+	return runQuery(cfg, args, nil)
+}
 
+// runQuery generates (or reuses from cache) and handles the command for one query.
+// Extra modifiers are added to the cache key on top of the flag-based ones.
+func runQuery(cfg *config.Config, args []string, modifiers []string) error {
 	// gather system context
-	ctx := gatherContext(args)
+	ctx := gatherContext(args, cfg)
 
-	// set up cache
-	commandCache, err := setupCache()
-	if err != nil {
-		return fmt.Errorf("failed to setup cache: %w", err)
+	// set up cache; nil when caching is off for this run
+	var commandCache *cache.Cache
+	if cfg.CacheEnabled && !noCacheFlag {
+		var err error
+		commandCache, err = setupCache(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to setup cache: %w", err)
+		}
+	}
+
+	modifiers = append(hashModifiers(), modifiers...)
+	if (explainFlag || breakdownFlag) && cfg.Language != "" {
+		// explanations in another language are a different answer
+		modifiers = append(modifiers, "language:"+cfg.Language)
 	}
+	hash := cache.HashQuery(ctx.Query, ctx.OS, ctx.CWD, ctx.Username, ctx.Shell, cfg.LLMAPI, cfg.Model, explainFlag, breakdownFlag, modifiers...)
+	s := &session{cfg: cfg, ctx: ctx, cache: commandCache, hash: hash}
 
-	hash := cache.HashQuery(ctx.Query, ctx.OS, ctx.CWD, ctx.Username, ctx.Shell, explainFlag, breakdownFlag)
-	if cached, ok := commandCache.Get(hash); ok {
-		return handleCachedCommand(cached, cfg)
+	if regenerateFlag {
+		// the cached answer is what the user wants to get away from
+		if cached, ok := s.cachedResponse(); ok {
+			s.previous, _, _ = parseResponse(cached)
+		}
+	}
+
+	// alternatives are always fresh; the chosen one replaces the cached answer
+	if candidatesFlag > 1 {
+		return runCandidates(s, candidatesFlag)
+	}
+
+	if cached, ok := s.cachedResponse(); ok && !regenerateFlag {
+		if err := checkRefusal(cached, cfg); err != nil {
+			return err
+		}
+		if err := checkASCIIOnly(cached); err != nil {
+			return err
+		}
+		recordCacheUse(commandCache, hash)
+		return handleCachedCommand(cached, s)
 	}
 
-	// create LLM instance
-	llmInstance, err := llm.New(cfg)
+	response, err := s.generate()
 	if err != nil {
-		return fmt.Errorf("failed to initialize LLM: %w", err)
+		return fmt.Errorf("failed to generate command: %w", err)
+	}
+
+	// refusals are not cached so the next attempt asks the model again
+	if err := checkRefusal(response, cfg); err != nil {
+		return err
+	}
+	if err := checkASCIIOnly(response); err != nil {
+		return err
 	}
 
+	if err := s.cacheResponse(response); err != nil {
+		return err
+	}
+
+	return handleGeneratedCommand(response, s)
+}
+
+// cachedResponse looks the query up in the cache, if caching is on.
+func (s *session) cachedResponse() (string, bool) {
+	if s.cache == nil {
+		return "", false
+	}
+	return s.cache.Get(s.hash)
+}
+
+// cacheResponse saves a generated response, if caching is on.
+func (s *session) cacheResponse(response string) error {
+	if s.cache == nil {
+		return nil
+	}
+	if err := s.cache.Set(s.hash, response); err != nil {
+		return fmt.Errorf("warning: failed to write to cache: %v", err)
+	}
+	return nil
+}
+
+func (s *session) promptOptions() prompt.Options {
+	return prompt.Options{
+		Explain:     explainFlag,
+		Breakdown:   breakdownFlag,
+		Annotate:    annotateFlag,
+		POSIX:       posixFlag,
+		Alternative: regenerateFlag,
+		Previous:    s.previous,
+	}
+}
+
+// hashModifiers lists the flags beyond explain/breakdown that change the answer,
+// so they get their own cache entries.
+func hashModifiers() []string {
+	var modifiers []string
+	if annotateFlag {
+		modifiers = append(modifiers, "annotate")
+	}
+	if posixFlag {
+		modifiers = append(modifiers, "posix")
+	}
+	return modifiers
+}
+
+// session holds what a single query needs to generate, regenerate or explain its command.
+type session struct {
+	cfg   *config.Config
+	ctx   prompt.Context
+	cache *cache.Cache
+	hash  string
+	// previous is the command --regenerate replaces, kept out of the new answer.
+	previous string
+
+	warnedDeprecated bool
+
+	// usage adds up the tokens reported for the last generate call, which may
+	// span two requests when regenerate_on_critical kicks in.
+	usage    llm.Usage
+	hasUsage bool
+}
+
+// warnDeprecatedModel prints a hint, once per session, when the configured
+// model is listed in deprecated_models, before the request fails with a 404.
+func (s *session) warnDeprecatedModel() {
+	if s.warnedDeprecated || s.cfg.LLMAPI == "local" {
+		return
+	}
+	s.warnedDeprecated = true
+
+	replacement, ok := s.cfg.DeprecatedModels[s.cfg.Model]
+	if !ok {
+		return
+	}
+	msg := fmt.Sprintf("  ⚠ model %s is deprecated", s.cfg.Model)
+	if replacement != "" {
+		msg += fmt.Sprintf(" • try: oneliner config set model %s", replacement)
+	}
+	fmt.Fprintln(os.Stderr, dimStyle.Render(msg))
+}
+
+func (s *session) generate() (string, error) {
+	s.usage, s.hasUsage = llm.Usage{}, false
+
+	response, err := s.generateWith(s.promptOptions())
+	if err != nil || !s.cfg.RegenerateOnCritical {
+		return response, err
+	}
+
+	// A critical answer to an ordinary request is more likely a hallucination or
+	// prompt injection than what the user wanted, so ask once more before showing it.
+	command, _, _ := parseResponse(response)
+	if executor.AssessCommandRisk(command, sudoFlag, s.cfg).Level < executor.RiskCritical {
+		return response, nil
+	}
+	fmt.Fprintln(os.Stderr, dimStyle.Render("  ⚠ generated command was critical risk • regenerating with safety instructions"))
+
+	opts := s.promptOptions()
+	opts.AvoidDestructive = true
+	return s.generateWith(opts)
+}
+
+func (s *session) generateWith(opts prompt.Options) (string, error) {
+	s.warnDeprecatedModel()
+
 	// generate prompt
-	promptText, err := prompt.Build(ctx, cfg, explainFlag, breakdownFlag)
+	promptText, err := prompt.Build(s.ctx, s.cfg, opts)
 	if err != nil {
-		return fmt.Errorf("failed to build prompt: %w", err)
+		return "", fmt.Errorf("failed to build prompt: %w", err)
 	}
 
-	response, err := generateWithSpinner(llmInstance, promptText)
+	return s.complete(promptText)
+}
+
+// recordUsage adds the tokens llmInstance reported for its last request.
+func (s *session) recordUsage(llmInstance llm.LLM) {
+	if reporter, ok := llmInstance.(llm.UsageReporter); ok {
+		if u, ok := reporter.LastUsage(); ok {
+			s.usage.PromptTokens += u.PromptTokens
+			s.usage.CompletionTokens += u.CompletionTokens
+			s.usage.TotalTokens += u.TotalTokens
+			s.hasUsage = true
+		}
+	}
+}
+
+// printUsage shows the tokens spent on the last generation when --usage or
+// show_usage is on. Providers that report nothing (e.g. local LLMs) print nothing.
+func (s *session) printUsage() {
+	if !(usageFlag || s.cfg.ShowUsage) || !s.hasUsage || explainPlainFlag {
+		return
+	}
+	fmt.Println(dimStyle.Render(fmt.Sprintf("  • %d tokens (%d prompt + %d completion)",
+		s.usage.TotalTokens, s.usage.PromptTokens, s.usage.CompletionTokens)))
+}
+
+func (s *session) explain(command string) (string, error) {
+	s.warnDeprecatedModel()
+
+	response, err := s.complete(prompt.BuildExplanation(s.ctx, s.cfg, command, false))
 	if err != nil {
-		return fmt.Errorf("failed to generate command: %w", err)
+		return "", err
 	}
 
-	// save to cache
-	if err := commandCache.Set(hash, response); err != nil {
-		return fmt.Errorf("warning: failed to write to cache: %v", err)
+	_, explanation, _ := parseResponse(response)
+	return explanation, nil
+}
+
+// loadConfig loads the --profile and the files given via --config, falling
+// back to the ONELINER_CONFIG_FILES list and then the default config path.
+func loadConfig() (*config.Config, error) {
+	paths, err := configFilePaths()
+	if err != nil {
+		return nil, err
 	}
+	return config.LoadFiles(paths)
+}
 
-	return handleGeneratedCommand(response, cfg)
+// configFilePaths lists the config files to layer. A --profile replaces the
+// default config as the base that any --config files are layered on.
+func configFilePaths() ([]string, error) {
+	paths := configPaths
+	if len(paths) == 0 {
+		for _, p := range filepath.SplitList(os.Getenv("ONELINER_CONFIG_FILES")) {
+			if strings.TrimSpace(p) != "" {
+				paths = append(paths, p)
+			}
+		}
+	}
+
+	if profileName != "" {
+		profile, err := config.ResolveProfile(profileName)
+		if err != nil {
+			return nil, err
+		}
+		paths = append([]string{profile}, paths...)
+	}
+	return paths, nil
 }
 
-func setupCache() (*cache.Cache, error) {
+func setupCache(cfg *config.Config) (*cache.Cache, error) {
 	cachePath := os.Getenv("ONELINER_CACHE_PATH")
 	if cachePath == "" {
 		home, err := os.UserHomeDir()
@@ -138,25 +501,59 @@ func setupCache() (*cache.Cache, error) {
 		}
 		cachePath = filepath.Join(home, ".cache", "oneliner", "commands.json")
 	}
-	return cache.New(cachePath)
+	return cache.New(cachePath, time.Duration(cfg.CacheTTLHours)*time.Hour, cfg.CacheMaxEntries)
 }
 
 func generateWithSpinner(llmInstance llm.LLM, promptText string) (string, error) {
-	loadingMsg := randomLoadingMessage()
-	s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
-	s.Prefix = loadingMsg + " "
-	s.Start()
-	defer func() {
-		s.Stop()
-		fmt.Print("\r\033[K")
-	}()
+	var response string
+	err := withSpinner(llmInstance, func(ctx context.Context) (err error) {
+		response, err = llmInstance.GenerateCommand(ctx, promptText)
+		return err
+	})
+	return response, err
+}
 
-	return llmInstance.GenerateCommand(promptText)
+// withSpinner runs request while a spinner is shown, unless llmInstance
+// streams its answer. Ctrl+C cancels the request and yields errCancelled.
+func withSpinner(llmInstance llm.LLM, request func(ctx context.Context) error) error {
+	// Ctrl+C aborts the in-flight request instead of leaving it running
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// streaming providers print the answer themselves; a spinner would garble it
+	if st, ok := llmInstance.(llm.Streamer); !ok || !st.Streaming() {
+		loadingMsg := randomLoadingMessage()
+		s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
+		s.Prefix = loadingMsg + " "
+		s.Start()
+		defer func() {
+			s.Stop()
+			// the spinner only draws on a terminal; keep piped output clean
+			if term.IsTerminal(int(os.Stdout.Fd())) {
+				fmt.Print("\r\033[K")
+			}
+		}()
+	}
+
+	if err := request(ctx); err != nil {
+		if ctx.Err() != nil {
+			return errCancelled
+		}
+		return err
+	}
+	return nil
 }
 
-func handleCachedCommand(cached string, cfg *config.Config) error {
+func handleCachedCommand(cached string, s *session) error {
 	command, explanation, breakdown := parseResponse(cached)
 	displayCommand(command, explanation, breakdown)
+	s.recordHistory(command, true)
+
+	if saveScriptPath != "" {
+		if err := saveScript(saveScriptPath, command, s.cfg.DefaultShell); err != nil {
+			return err
+		}
+	}
 
 	if clipboardFlag {
 		if err := copyToClipboard(command); err != nil {
@@ -164,23 +561,32 @@ func handleCachedCommand(cached string, cfg *config.Config) error {
 		}
 	}
 
+	if editFlag {
+		return editAndExecute(command, s)
+	}
+
 	if executeFlag {
-		return executeCommand(command, cfg)
+		return executeCommand(command, s.ctx.Query, s.cfg)
 	}
 
 	if interactiveFlag {
-		execute := displayInteractiveCommand(command, cfg)
-		if execute {
-			return executeCommand(command, cfg)
-		}
+		return runInteractive(command, explanation, s)
 	}
 
 	return nil
 }
 
-func handleGeneratedCommand(response string, cfg *config.Config) error {
+func handleGeneratedCommand(response string, s *session) error {
 	command, explanation, breakdown := parseResponse(response)
 	displayCommand(command, explanation, breakdown)
+	s.printUsage()
+	s.recordHistory(command, false)
+
+	if saveScriptPath != "" {
+		if err := saveScript(saveScriptPath, command, s.cfg.DefaultShell); err != nil {
+			return err
+		}
+	}
 
 	if clipboardFlag {
 		if err := copyToClipboard(command); err != nil {
@@ -188,23 +594,66 @@ func handleGeneratedCommand(response string, cfg *config.Config) error {
 		}
 	}
 
+	if editFlag {
+		return editAndExecute(command, s)
+	}
+
 	if executeFlag {
-		return executeCommand(command, cfg)
+		return executeCommand(command, s.ctx.Query, s.cfg)
 	}
 
 	if interactiveFlag {
-		execute := displayInteractiveCommand(command, cfg)
-		if execute {
-			return executeCommand(command, cfg)
-		}
+		return runInteractive(command, explanation, s)
 	}
 
 	return nil
 }
 
 func displayCommand(command, explanation, breakdown string) {
+	// raw mode is meant for command substitution: the command and nothing else
+	if rawFlag {
+		fmt.Println(command)
+		if found := invisibleRunes(command); len(found) > 0 {
+			fmt.Fprintln(os.Stderr, "warning: invisible characters:", strings.Join(found, ", "))
+		}
+		return
+	}
+
+	// plain mode is meant for pasting into docs: no styling, boxes or extras
+	if explainPlainFlag {
+		fmt.Println(strings.TrimSpace(explanation))
+		return
+	}
+
 	fmt.Println(commandStyle.Render(command))
 
+	if posixFlag {
+		if found := lintBashisms(command); len(found) > 0 {
+			fmt.Print(warningStyle.Render("  ⚠ possible bashisms: "))
+			fmt.Println(dimStyle.Render(strings.Join(found, ", ")))
+		}
+	}
+
+	if found := invisibleRunes(command); len(found) > 0 {
+		fmt.Print(warningStyle.Render("  ⚠ invisible characters: "))
+		fmt.Println(dimStyle.Render(strings.Join(found, ", ")))
+	}
+
+	if explainFlag && explanation != "" {
+		printSection("ℹ", "Explanation:", explanation)
+	}
+
+	if breakdownFlag && breakdown != "" {
+		if steps := parseBreakdownSteps(breakdown); prettyFlag && len(steps) > 1 {
+			printBreakdownTree(steps)
+		} else {
+			printSection("⤷", "Breakdown:", breakdown)
+		}
+	}
+}
+
+// contentWidth returns the width available for wrapped text below the command.
+func contentWidth() int {
 	width := 80
 	if fd := int(os.Stdout.Fd()); term.IsTerminal(fd) {
 		if w, _, err := term.GetSize(fd); err == nil && w > 0 {
@@ -216,6 +665,12 @@ func displayCommand(command, explanation, breakdown string) {
 	if contentWidth < 40 {
 		contentWidth = 40
 	}
+	return contentWidth
+}
+
+// printSection renders a headed, wrapped block of dim text below the command.
+func printSection(icon, heading, body string) {
+	contentWidth := contentWidth()
 
 	textBoxStyle := lipgloss.NewStyle().
 		Width(contentWidth).
@@ -225,46 +680,183 @@ func displayCommand(command, explanation, breakdown string) {
 
 	headingStyle := dimStyle.Bold(true)
 
-	if explainFlag && explanation != "" {
-		fmt.Println(dimStyle.Render("  ───────────────────────────────────────"))
-		fmt.Print(dimStyle.Render("  ℹ "))
-		fmt.Println(headingStyle.Render("Explanation:"))
-		fmt.Println(textBoxStyle.Render(explanation))
-		fmt.Println()
-	}
+	fmt.Println(dimStyle.Render("  ───────────────────────────────────────"))
+	fmt.Print(dimStyle.Render("  " + icon + " "))
+	fmt.Println(headingStyle.Render(heading))
+	fmt.Println(textBoxStyle.Render(body))
+	fmt.Println()
+}
 
-	if breakdownFlag && breakdown != "" {
-		fmt.Println(dimStyle.Render("  ───────────────────────────────────────"))
-		fmt.Print(dimStyle.Render("  ⤷ "))
-		fmt.Println(headingStyle.Render("Breakdown:"))
-		fmt.Println(textBoxStyle.Render(breakdown))
-		fmt.Println()
+type breakdownStep struct {
+	Number string
+	Text   string
+}
+
+var breakdownStepRegex = regexp.MustCompile(`^\s*(\d+)[.)]\s+(.*)$`)
+
+// parseBreakdownSteps splits a numbered breakdown into its steps. Lines that
+// don't start a new step are folded into the previous one; text before the
+// first step means the structure wasn't recognised and nil is returned.
+func parseBreakdownSteps(breakdown string) []breakdownStep {
+	var steps []breakdownStep
+	for _, line := range strings.Split(breakdown, "\n") {
+		if m := breakdownStepRegex.FindStringSubmatch(line); m != nil {
+			steps = append(steps, breakdownStep{Number: m[1], Text: strings.TrimSpace(m[2])})
+			continue
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if len(steps) == 0 {
+			return nil
+		}
+		steps[len(steps)-1].Text += " " + line
 	}
+	return steps
 }
 
-func displayInteractiveCommand(_ string, _ *config.Config) bool {
-	fmt.Println()
-	fmt.Print(cyanStyle.Render("Run command? [y/N]"))
+// printBreakdownTree renders breakdown steps as an indented tree, one block per step.
+func printBreakdownTree(steps []breakdownStep) {
+	numberStyle := cyanStyle
+	textStyle := lipgloss.NewStyle().Width(contentWidth() - 8).Foreground(lipgloss.Color("8"))
+
+	fmt.Println(dimStyle.Render("  ───────────────────────────────────────"))
+	fmt.Print(dimStyle.Render("  ⤷ "))
+	fmt.Println(dimStyle.Bold(true).Render("Breakdown:"))
+
+	for i, step := range steps {
+		last := i == len(steps)-1
+		branch, rail := "├─", "│ "
+		if last {
+			branch, rail = "└─", "  "
+		}
+
+		lines := strings.Split(textStyle.Render(step.Text), "\n")
+		for j, line := range lines {
+			if j == 0 {
+				fmt.Printf("    %s %s %s\n", dimStyle.Render(branch), numberStyle.Render(step.Number+"."), line)
+			} else {
+				fmt.Printf("    %s %s %s\n", dimStyle.Render(rail), strings.Repeat(" ", len(step.Number)+1), line)
+			}
+		}
+		if !last {
+			fmt.Println(dimStyle.Render("    │"))
+		}
+	}
 	fmt.Println()
+}
+
+// runInteractive shows the command palette and dispatches on the chosen action
+// until the user runs the command or cancels.
+func runInteractive(command, explanation string, s *session) error {
+	// the command has already been printed, which is all a pipe can use
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return errors.New("--interactive needs a terminal; the command is printed above")
+	}
+
+	for {
+		p := tea.NewProgram(executor.NewInteractionModel(command))
+		m, err := p.Run()
+		if err != nil {
+			return fmt.Errorf("failed to show interactive prompt: %w", err)
+		}
+		result := m.(executor.InteractionModel)
+		if result.Edited {
+			command = result.Command
+			explanation = ""
+		}
 
-	p := tea.NewProgram(executor.InterationModel("", "", false))
-	m, err := p.Run()
+		switch result.Action {
+		case executor.ActionRun:
+			return executeCommand(command, s.ctx.Query, s.cfg)
+
+		case executor.ActionCopy:
+			if err := copyToClipboard(command); err != nil {
+				fmt.Fprintln(os.Stderr, "Failed to copy to clipboard:", err)
+			} else {
+				fmt.Println(dimStyle.Render("  ✓ copied to clipboard"))
+			}
+
+		case executor.ActionExplain:
+			if explanation == "" {
+				explanation, err = s.explain(command)
+				if err != nil {
+					return fmt.Errorf("failed to explain command: %w", err)
+				}
+			}
+			fmt.Println()
+			printSection("ℹ", "Explanation:", explanation)
+
+		case executor.ActionRegenerate:
+			response, err := s.generate()
+			if err != nil {
+				return fmt.Errorf("failed to generate command: %w", err)
+			}
+			if err := checkRefusal(response, s.cfg); err != nil {
+				return err
+			}
+			if err := checkASCIIOnly(response); err != nil {
+				return err
+			}
+			if err := s.cacheResponse(response); err != nil {
+				return err
+			}
+
+			var breakdown string
+			command, explanation, breakdown = parseResponse(response)
+			fmt.Println()
+			displayCommand(command, explanation, breakdown)
+			s.printUsage()
+
+		default:
+			fmt.Print(cancelStyle.Render("  ✗ CANCELLED"))
+			fmt.Print(" ")
+			fmt.Println(dimStyle.Render("• user aborted"))
+			fmt.Println()
+			return nil
+		}
+	}
+}
+
+// editAndExecute lets the user adjust command and runs the result. The edited
+// command goes through the same risk assessment as a generated one.
+func editAndExecute(command string, s *session) error {
+	if annotateFlag {
+		// comments are stripped before running anyway; don't make the user edit them
+		command = stripShellComments(command)
+	}
+
+	edited, ok, err := executor.EditCommand(command)
 	if err != nil {
-		return false
+		return err
 	}
-	result := m.(executor.InteractionModel)
-	if result.Cancelled || !result.Confirmed {
+	if !ok {
 		fmt.Print(cancelStyle.Render("  ✗ CANCELLED"))
 		fmt.Print(" ")
 		fmt.Println(dimStyle.Render("• user aborted"))
-		fmt.Println()
-		return false
+		return nil
 	}
+	return executeCommand(edited, s.ctx.Query, s.cfg)
+}
 
-	return true
+func executeCommand(command, query string, cfg *config.Config) error {
+	execCmd, opts := prepareExecution(command, query)
+	if err := executor.Execute(execCmd, cfg, opts); err != nil {
+		return fmt.Errorf("failed to run command: %w", err)
+	}
+	return nil
 }
 
-func executeCommand(command string, cfg *config.Config) error {
+// prepareExecution returns the command to hand to the executor, with any
+// --sudo prefix, and the options recording what the user was shown.
+func prepareExecution(command, query string) (string, executor.Options) {
+	command = strings.TrimSpace(command)
+	if annotateFlag {
+		// the stripped form is what gets re-displayed and run
+		command = stripShellComments(command)
+	}
 	execCmd := command
 
 	if runtime.GOOS == "windows" && sudoFlag {
@@ -273,12 +865,55 @@ func executeCommand(command string, cfg *config.Config) error {
 		execCmd = "sudo " + execCmd
 	}
 
-	if err := executor.Execute(execCmd, cfg, sudoFlag); err != nil {
-		return fmt.Errorf("failed to run command: %w", err)
+	return execCmd, executor.Options{
+		Sudo:      sudoFlag,
+		Quiet:     quietFlag,
+		Displayed: command,
+		DryRun:    dryRunFlag,
+		Query:     query,
+		NoAudit:   noAuditFlag,
+		AssumeYes: assumeYes(),
+		Shell:     shellFlag,
+		Dir:       cwdFlag,
+		Output:    outputPath,
+		Overwrite: forceFlag,
+	}
+}
+
+// checkCwdFlag makes --cwd absolute, as the working directory shown to the
+// model should be, and checks that it is a directory.
+func checkCwdFlag() error {
+	if cwdFlag == "" {
+		return nil
+	}
+	dir, err := filepath.Abs(cwdFlag)
+	if err != nil {
+		return fmt.Errorf("invalid --cwd %s: %w", cwdFlag, err)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("--cwd %s is not a directory", cwdFlag)
 	}
+	cwdFlag = dir
 	return nil
 }
 
+// applyShellFlag makes --shell the shell commands are generated for.
+func applyShellFlag(cfg *config.Config) {
+	if shellFlag != "" {
+		cfg.DefaultShell = shellFlag
+	}
+}
+
+// assumeYes reports whether prompts should be answered with yes, via --yes or
+// a true ONELINER_ASSUME_YES.
+func assumeYes() bool {
+	if yesFlag {
+		return true
+	}
+	yes, err := strconv.ParseBool(os.Getenv("ONELINER_ASSUME_YES"))
+	return err == nil && yes
+}
+
 func detectShell() string {
 	if runtime.GOOS == "windows" {
 		comspec := os.Getenv("ComSpec")
@@ -304,9 +939,12 @@ func detectShell() string {
 	return shell
 }
 
-func gatherContext(args []string) prompt.Context {
+func gatherContext(args []string, cfg *config.Config) prompt.Context {
 	query := strings.Join(args, " ")
 	cwd, _ := os.Getwd()
+	if cwdFlag != "" {
+		cwd = cwdFlag
+	}
 	u, _ := user.Current()
 	username := "unknown"
 	if u != nil {
@@ -314,6 +952,10 @@ func gatherContext(args []string) prompt.Context {
 	}
 
 	shell := detectShell()
+	if shellFlag != "" {
+		// the shell the command is for, not the one oneliner was started from
+		shell = shellFlag
+	}
 
 	return prompt.Context{
 		Query:    query,
@@ -321,7 +963,29 @@ func gatherContext(args []string) prompt.Context {
 		CWD:      cwd,
 		Username: username,
 		Shell:    shell,
+		Distro:   linuxDistro(),
+		Tools:    availableTools(cfg.ProbeTools),
+	}
+}
+
+// toolLookups remembers PATH lookups so a batch run probes each tool once.
+var toolLookups = map[string]bool{}
+
+// availableTools returns the names that resolve on PATH, in the given order.
+func availableTools(names []string) []string {
+	var found []string
+	for _, name := range names {
+		ok, seen := toolLookups[name]
+		if !seen {
+			_, err := exec.LookPath(name)
+			ok = err == nil
+			toolLookups[name] = ok
+		}
+		if ok {
+			found = append(found, name)
+		}
 	}
+	return found
 }
 
 func parseResponse(response string) (command string, explanation string, breakdown string) {
@@ -340,7 +1004,7 @@ func parseResponse(response string) (command string, explanation string, breakdo
 	idxBrk := strings.Index(r, "BREAKDOWN:")
 
 	if idxExp == -1 && idxBrk == -1 {
-		command = strings.ReplaceAll(r, "```", "")
+		command = normalizeQuotes(strings.ReplaceAll(r, "```", ""))
 		return command, "", ""
 	}
 
@@ -374,13 +1038,104 @@ func parseResponse(response string) (command string, explanation string, breakdo
 		brkPart = strings.TrimSpace(strings.TrimPrefix(rest, "BREAKDOWN:"))
 	}
 
-	command = strings.ReplaceAll(command, "```", "")
+	command = normalizeQuotes(strings.ReplaceAll(command, "```", ""))
 	expPart = strings.ReplaceAll(expPart, "```", "")
 	brkPart = strings.ReplaceAll(brkPart, "```", "")
 
 	return command, expPart, brkPart
 }
 
+// checkRefusal reports errRefusal when the response's command starts with one of
+// the configured refusal phrases, so prose is never shown or run as a command.
+func checkRefusal(response string, cfg *config.Config) error {
+	command, _, _ := parseResponse(response)
+	normalized := strings.ToLower(strings.TrimSpace(command))
+	normalized = strings.NewReplacer("’", "'", "‘", "'").Replace(normalized)
+
+	for _, phrase := range cfg.RefusalPhrases {
+		phrase = strings.ToLower(strings.TrimSpace(phrase))
+		if phrase != "" && strings.HasPrefix(normalized, phrase) {
+			firstLine, _, _ := strings.Cut(strings.TrimSpace(command), "\n")
+			return fmt.Errorf("%w:\n  %s", errRefusal, firstLine)
+		}
+	}
+	return nil
+}
+
+// stripShellComments removes '#' comments from an annotated command and joins
+// its lines back into a single line that runs the same way. Quoted text and
+// '#' inside words (such as $# or ${#var}) are left alone.
+func stripShellComments(command string) string {
+	var b strings.Builder
+	inSingle, inDouble, escaped, lineStart := false, false, false, false
+
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+
+		if lineStart && (c == ' ' || c == '\t') {
+			continue
+		}
+		lineStart = false
+
+		if c == '\\' && !inSingle && !escaped && i+1 < len(command) && command[i+1] == '\n' {
+			// explicit line continuation; inside double quotes the shell drops
+			// it with nothing in its place
+			i++
+			if inDouble {
+				continue
+			}
+			joinLine(&b, true)
+			lineStart = true
+			continue
+		}
+
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\' && !inSingle:
+			escaped = true
+		case c == '\'' && !inDouble:
+			inSingle = !inSingle
+		case c == '"' && !inSingle:
+			inDouble = !inDouble
+		case c == '#' && !inSingle && !inDouble && (i == 0 || strings.ContainsRune(" \t\n;|&(", rune(command[i-1]))):
+			// skip to the end of the line, leaving the newline for the join logic below
+			for i+1 < len(command) && command[i+1] != '\n' {
+				i++
+			}
+			continue
+		case c == '\n' && !inSingle && !inDouble:
+			joinLine(&b, false)
+			lineStart = true
+			continue
+		}
+
+		b.WriteByte(c)
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+// joinLine ends the current line of b so the next one continues the same command:
+// line continuations and trailing operators are joined with a space, anything else with "; ".
+func joinLine(b *strings.Builder, continuation bool) {
+	line := strings.TrimRight(b.String(), " \t")
+	b.Reset()
+	b.WriteString(line)
+
+	switch {
+	case line == "":
+	case continuation:
+		b.WriteString(" ")
+	case strings.HasSuffix(line, "|"), strings.HasSuffix(line, "&"), strings.HasSuffix(line, ";"),
+		strings.HasSuffix(line, "("), strings.HasSuffix(line, "{"),
+		strings.HasSuffix(line, " do"), strings.HasSuffix(line, " then"), strings.HasSuffix(line, " else"):
+		b.WriteString(" ")
+	default:
+		b.WriteString("; ")
+	}
+}
+
 func copyToClipboard(command string) error {
 	return clipboard.WriteAll(command)
 }
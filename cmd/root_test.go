@@ -0,0 +1,358 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/dorochadev/oneliner/config"
+	"github.com/dorochadev/oneliner/internal/executor"
+	"github.com/dorochadev/oneliner/internal/prompt"
+	"github.com/muesli/termenv"
+)
+
+func TestStripShellComments(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    string
+	}{
+		{"no comment", "ls -la", "ls -la"},
+		{"trailing comment", "ls -la # list everything", "ls -la"},
+		{"comment per line", "cd /tmp # go to tmp\nls # list", "cd /tmp; ls"},
+		{"comment after pipe", "find . -name '*.go' | # go files\nwc -l", "find . -name '*.go' | wc -l"},
+		{"loop body", "for f in *; do # each file\n  echo $f\ndone", "for f in *; do echo $f; done"},
+		{"continuation", "tar -czf out.tgz \\\n  src # archive", "tar -czf out.tgz src"},
+		{"hash in single quotes", "echo '# not a comment'", "echo '# not a comment'"},
+		{"hash in double quotes", `echo "a # b"`, `echo "a # b"`},
+		{"hash inside a word", "echo a#b", "echo a#b"},
+		{"escaped hash", `echo \# not a comment`, `echo \# not a comment`},
+		{"continuation in double quotes", "echo \"abc\\\ndef\"", `echo "abcdef"`},
+		{"continuation in double quotes keeps indent", "echo \"abc \\\n  def\"", `echo "abc   def"`},
+		{"continuation in single quotes is literal", "echo 'abc\\\ndef'", "echo 'abc\\\ndef'"},
+		{"escaped backslash before newline", "echo a\\\\\nls", `echo a\\; ls`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripShellComments(tt.command); got != tt.want {
+				t.Errorf("stripShellComments(%q) = %q, want %q", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestExecutionRoundTrip checks that what reaches the executor is exactly the
+// parsed command plus an explicit sudo, and that the executor accepts it as
+// the displayed command.
+func TestExecutionRoundTrip(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("--sudo is ignored on Windows")
+	}
+	defer func(annotate, sudo bool) { annotateFlag, sudoFlag = annotate, sudo }(annotateFlag, sudoFlag)
+
+	tests := []struct {
+		name     string
+		response string
+		annotate bool
+		sudo     bool
+		want     string
+	}{
+		{"fenced with smart quotes", "```bash\nfind . -name “*.go”\n```", false, false, `find . -name "*.go"`},
+		{"explanation and sudo", "ls -la\nEXPLANATION: lists files\nBREAKDOWN: 1. ls", false, true, "sudo ls -la"},
+		{"annotated continuation", "tar -czf out.tgz \\\n  src # archive\nEXPLANATION: archives src", true, false, "tar -czf out.tgz src"},
+		{"annotated with sudo", "echo ‘hi’ # greet\nEXPLANATION: greets", true, true, "sudo echo 'hi'"},
+		{"annotated quoted continuation", "echo \"a\\\nb\" # joined", true, false, `echo "ab"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			annotateFlag, sudoFlag = tt.annotate, tt.sudo
+
+			command, _, _ := parseResponse(tt.response)
+			execCmd, opts := prepareExecution(command, "")
+			if execCmd != tt.want {
+				t.Fatalf("command passed to the executor = %q, want %q", execCmd, tt.want)
+			}
+
+			opts.DryRun, opts.Quiet = true, true
+			if err := executor.Execute(execCmd, config.Default(), opts); err != nil {
+				t.Errorf("Execute(%q) with displayed %q: %v", execCmd, opts.Displayed, err)
+			}
+		})
+	}
+
+	// an edited command is not parsed, so surrounding whitespace must not
+	// separate it from the sudo prefix
+	sudoFlag, annotateFlag = true, false
+	if execCmd, opts := prepareExecution("  rm -rf ./build\n", ""); execCmd != "sudo rm -rf ./build" || opts.Displayed != "rm -rf ./build" {
+		t.Errorf("prepareExecution of an edited command = %q displayed as %q", execCmd, opts.Displayed)
+	}
+}
+
+// mockRequest is one chat completion request received by mockOpenAI.
+type mockRequest struct {
+	Model  string
+	Prompt string
+}
+
+// mockOpenAI serves OpenAI chat completions that answer with answers in turn,
+// repeating the last one, and records the requests it receives.
+func mockOpenAI(t *testing.T, answers ...string) (*config.Config, *[]mockRequest) {
+	t.Helper()
+	var requests []mockRequest
+	var mu sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model    string `json:"model"`
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Messages) == 0 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		requests = append(requests, mockRequest{req.Model, req.Messages[len(req.Messages)-1].Content})
+		answer := answers[min(len(requests), len(answers))-1]
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":%q}}]}`, answer)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := config.Default()
+	cfg.LLMAPI = "openai"
+	cfg.APIKey = "test"
+	cfg.Model = "test-model"
+	cfg.OpenAIBaseURL = server.URL
+	cfg.MaxRetries = -1
+	cfg.CacheEnabled = false
+	return cfg, &requests
+}
+
+func TestRegenerateOnCritical(t *testing.T) {
+	const safe = "find . -name '*.tmp' -mtime +7 -print"
+	ctx := prompt.Context{Query: "clean up old temp files", OS: "linux", Shell: "bash", CWD: "/tmp"}
+
+	tests := []struct {
+		name       string
+		regenerate bool
+		want       string
+		requests   int
+	}{
+		{"enabled", true, safe, 2},
+		{"disabled", false, "rm -rf /", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, requests := mockOpenAI(t, "rm -rf /", safe)
+			cfg.RegenerateOnCritical = tt.regenerate
+			// not the default blacklist, so rm itself is not what makes it critical
+			cfg.BlacklistedBinaries = []string{"nc"}
+
+			s := &session{cfg: cfg, ctx: ctx}
+			response, err := s.generate()
+			if err != nil {
+				t.Fatalf("generate: %v", err)
+			}
+			if command, _, _ := parseResponse(response); command != tt.want {
+				t.Errorf("generated %q, want %q", command, tt.want)
+			}
+			if len(*requests) != tt.requests {
+				t.Fatalf("sent %d requests, want %d", len(*requests), tt.requests)
+			}
+			if strings.Contains((*requests)[0].Prompt, "previous answer to this task was a destructive command") {
+				t.Error("first prompt already asks to avoid destructive commands")
+			}
+			if tt.requests > 1 && !strings.Contains((*requests)[1].Prompt, "previous answer to this task was a destructive command") {
+				t.Errorf("retry prompt lacks the safety instructions:\n%s", (*requests)[1].Prompt)
+			}
+		})
+	}
+}
+
+func TestCheckRefusal(t *testing.T) {
+	cfg := config.Default()
+
+	tests := []struct {
+		name     string
+		response string
+		refused  bool
+	}{
+		{"command", "find . -name '*.log' -delete", false},
+		{"command with explanation", "ls -la\nEXPLANATION: I cannot stress enough how useful this is", false},
+		{"i cannot", "I cannot help with that request.", true},
+		{"curly apostrophe", "I’m sorry, but I can’t assist with that.", true},
+		{"as an ai", "As an AI language model, I won't generate destructive commands.", true},
+		{"fenced refusal", "```\nI'm unable to provide that command.\n```", true},
+		{"refusal with explanation", "I can't do that.\nEXPLANATION: it would wipe the disk", true},
+		{"phrase later in the command", "echo \"I'm sorry\"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkRefusal(tt.response, cfg)
+			if refused := errors.Is(err, errRefusal); refused != tt.refused {
+				t.Errorf("checkRefusal(%q) = %v, want refusal %v", tt.response, err, tt.refused)
+			}
+		})
+	}
+
+	// the phrase list is configurable
+	custom := config.Default()
+	custom.RefusalPhrases = []string{"nope"}
+	if err := checkRefusal("Nope, not doing that", custom); !errors.Is(err, errRefusal) {
+		t.Errorf("custom refusal phrase not detected: %v", err)
+	}
+	if err := checkRefusal("I cannot help with that.", custom); err != nil {
+		t.Errorf("default phrase still detected after replacing the list: %v", err)
+	}
+}
+
+func TestParseBreakdownSteps(t *testing.T) {
+	tests := []struct {
+		name      string
+		breakdown string
+		want      []breakdownStep
+	}{
+		{
+			"numbered with dots",
+			"1. find . searches the current dir\n2. -name '*.go' matches Go files\n3. -delete removes them",
+			[]breakdownStep{{"1", "find . searches the current dir"}, {"2", "-name '*.go' matches Go files"}, {"3", "-delete removes them"}},
+		},
+		{
+			"parens, indent and blank lines",
+			"  1) ls lists files\n\n  2) -la shows hidden ones",
+			[]breakdownStep{{"1", "ls lists files"}, {"2", "-la shows hidden ones"}},
+		},
+		{
+			"continuation lines",
+			"1. tar -czf archive.tgz\n   creates a gzipped archive\n2. src/ is the input",
+			[]breakdownStep{{"1", "tar -czf archive.tgz creates a gzipped archive"}, {"2", "src/ is the input"}},
+		},
+		{"prose", "This command lists every file, including hidden ones.", nil},
+		{"prose before steps", "Here is how it works:\n1. ls lists files", nil},
+		{"empty", "", nil},
+		{"number without space", "1.5 seconds is the delay", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseBreakdownSteps(tt.breakdown)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseBreakdownSteps(%q) = %q, want %q", tt.breakdown, got, tt.want)
+			}
+		})
+	}
+}
+
+// captureStdout returns what f writes to os.Stdout.
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	out := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- string(data)
+	}()
+
+	f()
+	w.Close()
+	return <-out
+}
+
+func TestExplainPlainHasNoANSI(t *testing.T) {
+	defer func(profile termenv.Profile) { lipgloss.SetColorProfile(profile) }(lipgloss.ColorProfile())
+	defer func(plain, explain, breakdown, pretty bool) {
+		explainPlainFlag, explainFlag, breakdownFlag, prettyFlag = plain, explain, breakdown, pretty
+	}(explainPlainFlag, explainFlag, breakdownFlag, prettyFlag)
+	// styles would render escapes, so any that leak through are caught
+	lipgloss.SetColorProfile(termenv.TrueColor)
+	t.Setenv("ONELINER_HISTORY_PATH", filepath.Join(t.TempDir(), "history.jsonl"))
+
+	const response = "find . -name '*.go' | xargs wc -l\n" +
+		"EXPLANATION: Counts the lines of every Go file.\n" +
+		"BREAKDOWN:\n1. find . -name '*.go' lists Go files\n2. xargs wc -l counts their lines"
+	s := &session{cfg: config.Default(), ctx: prompt.Context{Query: "count lines of go code"}}
+
+	explainFlag, breakdownFlag, prettyFlag = true, true, true
+	explainPlainFlag = false
+	if styled := captureStdout(t, func() { handleGeneratedCommand(response, s) }); !strings.Contains(styled, "\x1b[") {
+		t.Fatalf("styled output has no escapes, so the check below proves nothing:\n%q", styled)
+	}
+
+	explainPlainFlag = true
+	plain := captureStdout(t, func() {
+		if err := handleGeneratedCommand(response, s); err != nil {
+			t.Errorf("handleGeneratedCommand: %v", err)
+		}
+	})
+	if strings.Contains(plain, "\x1b") {
+		t.Errorf("--explain-plain output contains escape sequences: %q", plain)
+	}
+	if want := "Counts the lines of every Go file.\n"; plain != want {
+		t.Errorf("--explain-plain output = %q, want %q", plain, want)
+	}
+}
+
+// TestCacheHitUnwritableCache checks that counting a cache hit is best-effort:
+// a cache file that cannot be written must not stop the cached command.
+func TestCacheHitUnwritableCache(t *testing.T) {
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, "commands.json")
+	t.Setenv("ONELINER_CACHE_PATH", cachePath)
+	t.Setenv("ONELINER_HISTORY_PATH", filepath.Join(dir, "history.jsonl"))
+
+	cfg, requests := mockOpenAI(t, "du -sh *")
+	cfg.CacheEnabled = true
+
+	if out := captureStdout(t, func() {
+		if err := runQuery(cfg, []string{"show folder sizes"}, nil); err != nil {
+			t.Fatalf("first runQuery: %v", err)
+		}
+	}); !strings.Contains(out, "du -sh *") {
+		t.Fatalf("first run printed %q", out)
+	}
+
+	// a directory where the temp file goes makes every cache write fail,
+	// even for root
+	if err := os.Mkdir(cachePath+".tmp", 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	out := captureStdout(t, func() {
+		if err := runQuery(cfg, []string{"show folder sizes"}, nil); err != nil {
+			t.Errorf("cache hit with an unwritable cache: %v", err)
+		}
+	})
+	if !strings.Contains(out, "du -sh *") {
+		t.Errorf("cached command not shown: %q", out)
+	}
+	if len(*requests) != 1 {
+		t.Errorf("sent %d requests, want 1 (the second run is a cache hit)", len(*requests))
+	}
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// shebangs maps a shell name to the interpreter line for a saved script.
+var shebangs = map[string]string{
+	"sh":         "#!/bin/sh",
+	"dash":       "#!/bin/sh",
+	"bash":       "#!/usr/bin/env bash",
+	"zsh":        "#!/usr/bin/env zsh",
+	"fish":       "#!/usr/bin/env fish",
+	"ksh":        "#!/usr/bin/env ksh",
+	"powershell": "#!/usr/bin/env pwsh",
+	"pwsh":       "#!/usr/bin/env pwsh",
+}
+
+// shebangFor returns the shebang line for shell, which may be a name or a path
+// such as /bin/zsh. --posix commands always get /bin/sh.
+func shebangFor(shell string) (string, error) {
+	if posixFlag {
+		return shebangs["sh"], nil
+	}
+
+	name := strings.ToLower(filepath.Base(strings.TrimSpace(shell)))
+	name = strings.TrimSuffix(name, ".exe")
+	if name == "" || name == "." {
+		name = "bash"
+	}
+	if line, ok := shebangs[name]; ok {
+		return line, nil
+	}
+	return "", fmt.Errorf("cannot save a script for shell %q", shell)
+}
+
+// saveScript writes command to path as an executable script for shell. An
+// existing file is only replaced with --force. Multi-line (e.g. annotated)
+// commands are written as they were shown.
+func saveScript(path, command, shell string) error {
+	shebang, err := shebangFor(shell)
+	if err != nil {
+		return err
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if forceFlag {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0755)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create script: %w", err)
+	}
+
+	_, err = fmt.Fprintf(f, "%s\n%s\n", shebang, strings.TrimSpace(command))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write script: %w", err)
+	}
+
+	// the mode passed to OpenFile is masked by umask and ignored for existing files
+	if err := os.Chmod(path, 0755); err != nil {
+		return fmt.Errorf("failed to make script executable: %w", err)
+	}
+
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	fmt.Println(dimStyle.Render("  ✓ saved script to " + path))
+	return nil
+}
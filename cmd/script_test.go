@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestShebangFor(t *testing.T) {
+	defer func(v bool) { posixFlag = v }(posixFlag)
+	posixFlag = false
+
+	tests := []struct {
+		shell   string
+		want    string
+		wantErr bool
+	}{
+		{"bash", "#!/usr/bin/env bash", false},
+		{"/bin/bash", "#!/usr/bin/env bash", false},
+		{"/usr/local/bin/zsh", "#!/usr/bin/env zsh", false},
+		{"fish", "#!/usr/bin/env fish", false},
+		{"ksh", "#!/usr/bin/env ksh", false},
+		{"sh", "#!/bin/sh", false},
+		{"/bin/dash", "#!/bin/sh", false},
+		{"pwsh", "#!/usr/bin/env pwsh", false},
+		{"PowerShell.exe", "#!/usr/bin/env pwsh", false},
+		{"", "#!/usr/bin/env bash", false},
+		{"  zsh  ", "#!/usr/bin/env zsh", false},
+		{"cmd", "", true},
+		{"tcsh", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			got, err := shebangFor(tt.shell)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("shebangFor(%q) = %q, %v; want %q, error %v", tt.shell, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+
+	posixFlag = true
+	if got, _ := shebangFor("zsh"); got != "#!/bin/sh" {
+		t.Errorf("shebangFor with --posix = %q, want #!/bin/sh", got)
+	}
+}
+
+func TestSaveScript(t *testing.T) {
+	defer func(posix, force bool) { posixFlag, forceFlag = posix, force }(posixFlag, forceFlag)
+	posixFlag, forceFlag = false, false
+
+	path := filepath.Join(t.TempDir(), "cleanup.sh")
+	if err := saveScript(path, "  find . -name '*.tmp' -delete\n", "zsh"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "#!/usr/bin/env zsh\nfind . -name '*.tmp' -delete\n"; string(data) != want {
+		t.Errorf("script = %q, want %q", data, want)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0111 == 0 {
+		t.Errorf("script mode %v is not executable", info.Mode())
+	}
+
+	if err := saveScript(path, "ls", "bash"); err == nil {
+		t.Error("saveScript replaced an existing file without --force")
+	}
+
+	forceFlag = true
+	if err := saveScript(path, "ls", "bash"); err != nil {
+		t.Fatalf("saveScript with --force: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "#!/usr/bin/env bash\nls\n" {
+		t.Errorf("script after --force = %q", data)
+	}
+}
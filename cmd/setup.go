@@ -1,15 +1,18 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
-	"os"
-	"path/filepath"
+	"maps"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/dorochadev/oneliner/config"
+	"github.com/dorochadev/oneliner/internal/llm"
 	"github.com/spf13/cobra"
 )
 
@@ -34,6 +37,24 @@ type setupModel struct {
 	cancelled        bool
 	apiOptions       []string
 	modelSuggestions map[string][]string
+	suggestionIdx    int
+}
+
+// ollamaModelsMsg carries the models installed on the configured Ollama server.
+type ollamaModelsMsg []string
+
+// fetchOllamaModels asks an Ollama endpoint for its installed models. Errors
+// and slow servers are ignored; the static suggestions stay in place.
+func fetchOllamaModels(endpoint string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		models, err := llm.ListOllamaModels(ctx, endpoint)
+		if err != nil || len(models) == 0 {
+			return nil
+		}
+		return ollamaModelsMsg(models)
+	}
 }
 
 var setupCmd = &cobra.Command{
@@ -41,14 +62,7 @@ var setupCmd = &cobra.Command{
 	Short: "Interactive setup wizard for oneliner configuration",
 	Long:  "Guide you through configuring your LLM provider, API key, and other settings.",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		cfgPath := ""
-		if cfgPath == "" {
-			home, err := os.UserHomeDir()
-			if err != nil {
-				return fmt.Errorf("failed to get home dir: %w", err)
-			}
-			cfgPath = filepath.Join(home, ".config", "oneliner", "config.json")
-		}
+		cfgPath := config.DefaultPath()
 
 		// Load existing config or create default
 		cfg, err := config.Load(cfgPath)
@@ -82,14 +96,18 @@ func init() {
 	rootCmd.AddCommand(setupCmd)
 }
 
-func initialSetupModel(cfg *config.Config, cfgPath string) setupModel {
-	apiOptions := []string{"openai", "claude", "local"}
+// modelSuggestions lists known models per provider; the first one is the default.
+var modelSuggestions = map[string][]string{
+	"openai":  {"gpt-4o", "gpt-4o-mini", "gpt-4-turbo"},
+	"claude":  {"claude-sonnet-4-5-20250929", "claude-opus-4-1-20250805"},
+	"mistral": {"mistral-large-latest", "mistral-small-latest", "codestral-latest"},
+	"cohere":  {"command-r-plus", "command-r"},
+	"gemini":  {"gemini-1.5-pro", "gemini-1.5-flash"},
+	"local":   {"llama3", "mistral", "codellama"},
+}
 
-	modelSuggestions := map[string][]string{
-		"openai": {"gpt-4o", "gpt-4o-mini", "gpt-4-turbo", "gpt-3.5-turbo"},
-		"claude": {"claude-sonnet-4-5-20250929", "claude-3-5-sonnet-20241022", "claude-3-opus-20240229"},
-		"local":  {"llama3", "mistral", "codellama"},
-	}
+func initialSetupModel(cfg *config.Config, cfgPath string) setupModel {
+	apiOptions := slices.Clone(llm.Providers)
 
 	// Create text inputs for configuration
 	inputs := make([]textinput.Model, 4)
@@ -149,7 +167,8 @@ func initialSetupModel(cfg *config.Config, cfgPath string) setupModel {
 		cfg:              cfg,
 		cfgPath:          cfgPath,
 		apiOptions:       apiOptions,
-		modelSuggestions: modelSuggestions,
+		modelSuggestions: maps.Clone(modelSuggestions),
+		suggestionIdx:    -1,
 	}
 }
 
@@ -159,6 +178,11 @@ func (m setupModel) Init() tea.Cmd {
 
 func (m setupModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case ollamaModelsMsg:
+		m.modelSuggestions["local"] = msg
+		m.suggestionIdx = -1
+		return m, nil
+
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c", "esc":
@@ -172,11 +196,17 @@ func (m setupModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.step == 0 && m.selectedAPI > 0 {
 				m.selectedAPI--
 			}
+			if m.step == 2 && msg.String() == "up" {
+				return m.pickSuggestion(-1), nil
+			}
 
 		case "down", "j":
 			if m.step == 0 && m.selectedAPI < len(m.apiOptions)-1 {
 				m.selectedAPI++
 			}
+			if m.step == 2 && msg.String() == "down" {
+				return m.pickSuggestion(1), nil
+			}
 
 		case "tab", "shift+tab":
 			// Skip to next/previous relevant input based on API selection
@@ -230,12 +260,34 @@ func (m setupModel) handleEnter() (tea.Model, tea.Cmd) {
 		if nextInput >= 0 && nextInput < len(m.inputs) {
 			m.inputs[nextInput].Focus()
 		}
+		if m.step == 2 && m.cfg.LLMAPI == "local" && llm.IsOllamaEndpoint(m.cfg.LocalLLMEndpoint) {
+			return m, tea.Batch(textinput.Blink, fetchOllamaModels(m.cfg.LocalLLMEndpoint))
+		}
 		return m, textinput.Blink
 	}
 
 	return m, nil
 }
 
+// pickSuggestion moves through the model suggestions and fills the model input.
+func (m setupModel) pickSuggestion(delta int) setupModel {
+	suggestions := m.modelSuggestions[m.cfg.LLMAPI]
+	if len(suggestions) == 0 {
+		return m
+	}
+	switch {
+	case m.suggestionIdx < 0 && delta < 0:
+		m.suggestionIdx = len(suggestions) - 1
+	case m.suggestionIdx < 0:
+		m.suggestionIdx = 0
+	default:
+		m.suggestionIdx = (m.suggestionIdx + delta + len(suggestions)) % len(suggestions)
+	}
+	m.inputs[1].SetValue(suggestions[m.suggestionIdx])
+	m.inputs[1].CursorEnd()
+	return m
+}
+
 func (m setupModel) handleTab(reverse bool) (tea.Model, tea.Cmd) {
 	currentIdx := m.getInputIndex()
 	if currentIdx < 0 {
@@ -431,6 +483,12 @@ func (m setupModel) viewCredentials() string {
 			b.WriteString(hintStyle.Render("  Get your key: https://platform.openai.com/api-keys"))
 		} else if apiType == "claude" {
 			b.WriteString(hintStyle.Render("  Get your key: https://console.anthropic.com/"))
+		} else if apiType == "mistral" {
+			b.WriteString(hintStyle.Render("  Get your key: https://console.mistral.ai/api-keys"))
+		} else if apiType == "cohere" {
+			b.WriteString(hintStyle.Render("  Get your key: https://dashboard.cohere.com/api-keys"))
+		} else if apiType == "gemini" {
+			b.WriteString(hintStyle.Render("  Get your key: https://aistudio.google.com/app/apikey"))
 		}
 	}
 
@@ -454,12 +512,17 @@ func (m setupModel) viewModel() string {
 	b.WriteString("\n\n")
 
 	suggestions := m.modelSuggestions[m.cfg.LLMAPI]
-	if len(suggestions) > 0 {
-		b.WriteString(hintStyle.Render("  Suggestions: " + strings.Join(suggestions, ", ")))
+	for i, name := range suggestions {
+		if i == m.suggestionIdx {
+			b.WriteString(cursorStyle.Render("  ❯ ") + selectedStyle.Render(name))
+		} else {
+			b.WriteString("    " + hintStyle.Render(name))
+		}
+		b.WriteString("\n")
 	}
 
-	b.WriteString("\n\n")
-	b.WriteString(hintStyle.Render("  enter continue • tab navigate • esc cancel"))
+	b.WriteString("\n")
+	b.WriteString(hintStyle.Render("  ↑/↓ pick • enter continue • tab navigate • esc cancel"))
 	b.WriteString("\n")
 
 	return b.String()
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/dorochadev/oneliner/config"
+	"github.com/spf13/cobra"
+)
+
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Manage reusable query templates",
+}
+
+var templateAddCmd = &cobra.Command{
+	Use:   "add [name] [template]",
+	Short: "Save a query template, e.g. 'backup {{.dir}} to {{.dest}}'",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		text := args[1]
+
+		if _, err := parseQueryTemplate(name, text); err != nil {
+			return err
+		}
+
+		cfg, err := config.Load("")
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		if cfg.Templates == nil {
+			cfg.Templates = make(map[string]string)
+		}
+		oldValue, existed := cfg.Templates[name]
+		cfg.Templates[name] = text
+
+		if err := config.Save("", cfg); err != nil {
+			return fmt.Errorf("failed to write config: %w", err)
+		}
+
+		fmt.Println()
+		fmt.Print(successStyle.Render("  ✓ Template saved"))
+		fmt.Println()
+		fmt.Println()
+
+		fmt.Printf("  %s\n", keyStyle.Render(name))
+		if existed && oldValue != text {
+			fmt.Printf("    %s → %s\n", hintStyle.Render(oldValue), valueStyle.Render(text))
+		} else {
+			fmt.Printf("    %s\n", valueStyle.Render(text))
+		}
+		fmt.Println()
+
+		return nil
+	},
+}
+
+var templateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved query templates",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load("")
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		if len(cfg.Templates) == 0 {
+			fmt.Println("No templates saved")
+			return nil
+		}
+
+		names := make([]string, 0, len(cfg.Templates))
+		maxNameLen := 0
+		for name := range cfg.Templates {
+			names = append(names, name)
+			if len(name) > maxNameLen {
+				maxNameLen = len(name)
+			}
+		}
+		sort.Strings(names)
+
+		fmt.Println()
+		fmt.Println(headerStyle.Render("  Templates"))
+		fmt.Println()
+
+		for _, name := range names {
+			padding := strings.Repeat(" ", maxNameLen-len(name))
+			fmt.Printf("  %s%s %s\n",
+				keyStyle.Render(name),
+				padding,
+				valueStyle.Render(cfg.Templates[name]))
+		}
+
+		fmt.Println()
+		fmt.Println(hintStyle.Render("  Use 'oneliner template run <name> key=value ...' to generate"))
+		fmt.Println()
+
+		return nil
+	},
+}
+
+var templateRunCmd = &cobra.Command{
+	Use:   "run [name] [key=value...]",
+	Short: "Fill a saved template and generate a command from it",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		text, ok := cfg.Templates[name]
+		if !ok {
+			return fmt.Errorf("unknown template: %s", name)
+		}
+
+		vars, err := parseTemplateVars(args[1:])
+		if err != nil {
+			return err
+		}
+
+		query, err := renderQueryTemplate(name, text, vars)
+		if err != nil {
+			return err
+		}
+
+		return run(cmd, []string{query})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(templateCmd)
+	templateCmd.AddCommand(templateAddCmd)
+	templateCmd.AddCommand(templateListCmd)
+	templateCmd.AddCommand(templateRunCmd)
+
+	addGenerationFlags(templateRunCmd.Flags())
+}
+
+func parseQueryTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template %s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+func parseTemplateVars(args []string) (map[string]string, error) {
+	vars := make(map[string]string, len(args))
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid template variable %q (expected key=value)", arg)
+		}
+		vars[strings.TrimSpace(key)] = value
+	}
+	return vars, nil
+}
+
+func renderQueryTemplate(name, text string, vars map[string]string) (string, error) {
+	tmpl, err := parseQueryTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, vars); err != nil {
+		return "", fmt.Errorf("failed to fill template %s: %w", name, err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/dorochadev/oneliner/config"
+	"github.com/spf13/cobra"
+)
+
+var whichCmd = &cobra.Command{
+	Use:   "which",
+	Short: "Show the effective configuration and where each value comes from",
+	Long: "Show the configuration a query would use after layering the --config files " +
+		"(or ONELINER_CONFIG_FILES) and the environment, annotating the source of every value.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		paths, err := configFilePaths()
+		if err != nil {
+			return err
+		}
+		cfg, sources, err := config.LoadFilesWithSources(paths)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		v := reflect.ValueOf(cfg).Elem()
+		t := v.Type()
+
+		maxKeyLen := 0
+		keys := make([]string, v.NumField())
+		values := make([]string, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			keys[i] = t.Field(i).Tag.Get("json")
+			values[i], _ = formatConfigValue(cfg, keys[i], v.Field(i))
+			maxKeyLen = max(maxKeyLen, len(keys[i]))
+		}
+
+		fmt.Println()
+		fmt.Println(headerStyle.Render("  Effective configuration"))
+		fmt.Println()
+
+		for i, key := range keys {
+			source := describeSource(sources[key])
+			if key == "api_key" && cfg.APIKey == "" {
+				if _, env := config.APIKeyFromEnv(cfg.LLMAPI); env != "" {
+					source = "from $" + env
+				}
+			}
+
+			fmt.Printf("  %s%s %s %s\n",
+				keyStyle.Render(key),
+				strings.Repeat(" ", maxKeyLen-len(key)),
+				values[i],
+				hintStyle.Render("• "+source))
+		}
+		fmt.Println()
+
+		return nil
+	},
+}
+
+// describeSource turns a config.Sources entry into a "from ..." annotation,
+// shortening paths under the home directory to ~.
+func describeSource(source string) string {
+	if source == config.SourceDefault || source == "" {
+		return config.SourceDefault
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(home, source); err == nil && !strings.HasPrefix(rel, "..") {
+			source = filepath.Join("~", rel)
+		}
+	}
+	return "from " + source
+}
+
+func init() {
+	rootCmd.AddCommand(whichCmd)
+	whichCmd.Flags().StringArrayVar(&configPaths, "config", nil, "Config file to layer, or - for stdin (repeatable, as for queries)")
+	whichCmd.Flags().StringVar(&profileName, "profile", "", "Use the named config profile, as for queries")
+}
@@ -3,22 +3,98 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
 	"runtime"
+	"slices"
 	"strings"
 )
 
 type Config struct {
-	LLMAPI              string   `json:"llm_api"`
-	APIKey              string   `json:"api_key"`
-	Model               string   `json:"model"`
-	DefaultShell        string   `json:"default_shell"`
-	LocalLLMEndpoint    string   `json:"local_llm_endpoint"`
-	ClaudeMaxTokens     int      `json:"claude_max_tokens"`
-	RequestTimeout      int      `json:"request_timeout"`
-	ClientTimeout       int      `json:"client_timeout"`
-	BlacklistedBinaries []string `json:"blacklisted_binaries"`
+	LLMAPI               string            `json:"llm_api"`
+	APIKey               string            `json:"api_key"`
+	Model                string            `json:"model"`
+	DefaultShell         string            `json:"default_shell"`
+	LocalLLMEndpoint     string            `json:"local_llm_endpoint"`
+	ClaudeMaxTokens      int               `json:"claude_max_tokens"`
+	RequestTimeout       int               `json:"request_timeout"`
+	ClientTimeout        int               `json:"client_timeout"`
+	BlacklistedBinaries  []string          `json:"blacklisted_binaries"`
+	Templates            map[string]string `json:"templates"`
+	RefusalPhrases       []string          `json:"refusal_phrases"`
+	CustomHeaders        map[string]string `json:"custom_headers"`
+	PreviewGlobMatches   bool              `json:"preview_glob_matches"`
+	AutoApproveReasons   []string          `json:"auto_approve_reasons"`
+	TrustedDirs          []string          `json:"trusted_dirs"`
+	Stream               bool              `json:"stream"`
+	DeprecatedModels     map[string]string `json:"deprecated_models"`
+	MaxRetries           int               `json:"max_retries"`
+	SandboxCommand       string            `json:"sandbox_command"`
+	ProxyURL             string            `json:"proxy_url"`
+	InsecureTLS          bool              `json:"insecure_tls"`
+	RegenerateOnCritical bool              `json:"regenerate_on_critical"`
+	OpenAIBaseURL        string            `json:"openai_base_url"`
+	OpenAIPath           string            `json:"openai_path"`
+	Temperature          *float64          `json:"temperature"`
+	TopP                 *float64          `json:"top_p"`
+	ShellInstructions    map[string]string `json:"shell_instructions"`
+	ShowUsage            bool              `json:"show_usage"`
+	FallbackProviders    []string          `json:"fallback_providers"`
+	ProviderPriority     map[string]int    `json:"provider_priority"`
+	LocalHeaders         map[string]string `json:"local_headers"`
+	RiskDisplay          string            `json:"risk_display"`
+	CacheTTLHours        int               `json:"cache_ttl_hours"`
+	CacheMaxEntries      int               `json:"cache_max_entries"`
+	ProviderTimeouts     map[string]int    `json:"provider_timeouts"`
+	CacheEnabled         bool              `json:"cache_enabled"`
+	PromptTemplate       string            `json:"prompt_template"`
+	FewShotExamples      []FewShotExample  `json:"few_shot_examples"`
+	ProbeTools           []string          `json:"probe_tools"`
+	Language             string            `json:"language"`
+	AuditLog             string            `json:"audit_log"`
+	AuditEnabled         bool              `json:"audit_enabled"`
+	AllowlistedBinaries  []string          `json:"allowlisted_binaries"`
+	CustomRiskPatterns   []RiskPattern     `json:"custom_risk_patterns"`
+	MaxAutoRiskLevel     string            `json:"max_auto_risk_level"`
+}
+
+// FewShotExample is a demonstration query and the command that answers it.
+type FewShotExample struct {
+	Query   string `json:"query"`
+	Command string `json:"command"`
+}
+
+// RiskPattern is a user-defined risk check: commands matching Regex are
+// reported with Description and rated at least Level.
+type RiskPattern struct {
+	Regex       string `json:"regex"`
+	Description string `json:"description"`
+	Level       string `json:"level"`
+}
+
+// RiskPatternLevels are the accepted values of RiskPattern.Level and of a
+// non-empty max_auto_risk_level.
+var RiskPatternLevels = []string{"low", "medium", "high", "critical"}
+
+// validateRiskPatterns reports the first custom_risk_patterns entry that
+// would be unusable, so a typo surfaces at load time instead of the check
+// silently never firing.
+func validateRiskPatterns(patterns []RiskPattern) error {
+	for i, p := range patterns {
+		if strings.TrimSpace(p.Regex) == "" {
+			return fmt.Errorf("custom_risk_patterns[%d]: regex is empty", i)
+		}
+		if _, err := regexp.Compile(p.Regex); err != nil {
+			return fmt.Errorf("custom_risk_patterns[%d]: invalid regex %q: %w", i, p.Regex, err)
+		}
+		if !slices.Contains(RiskPatternLevels, strings.ToLower(strings.TrimSpace(p.Level))) {
+			return fmt.Errorf("custom_risk_patterns[%d]: level must be one of %s, got %q", i, strings.Join(RiskPatternLevels, ", "), p.Level)
+		}
+	}
+	return nil
 }
 
 // Load loads config from disk, ensuring any missing fields are added.
@@ -48,7 +124,33 @@ func Load(customPath string) (*Config, error) {
 	if err := json.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config file into struct: %w", err)
 	}
+	fillZeroableDefaults(&cfg, raw)
 
+	def := defaultConfig()
+	updated := fillDefaults(&cfg)
+
+	// --- Automatic new-field detection ---
+	defMap := structToMap(def)
+	for k := range defMap {
+		if _, ok := raw[k]; !ok {
+			updated = true
+			break
+		}
+	}
+
+	// Save back if updated or new fields detected.
+	if updated {
+		if err := Save(path, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to update config: %w", err)
+		}
+	}
+
+	return &cfg, nil
+}
+
+// fillDefaults patches missing or zero-value fields of cfg with their
+// defaults and reports whether anything changed.
+func fillDefaults(cfg *Config) bool {
 	def := defaultConfig()
 	updated := false
 
@@ -74,6 +176,14 @@ func Load(customPath string) (*Config, error) {
 		cfg.LocalLLMEndpoint = def.LocalLLMEndpoint
 		updated = true
 	}
+	if strings.TrimSpace(cfg.RiskDisplay) == "" {
+		cfg.RiskDisplay = def.RiskDisplay
+		updated = true
+	}
+	if strings.TrimSpace(cfg.AuditLog) == "" {
+		cfg.AuditLog = def.AuditLog
+		updated = true
+	}
 
 	// --- Integers ---
 	if cfg.ClaudeMaxTokens == 0 {
@@ -88,30 +198,256 @@ func Load(customPath string) (*Config, error) {
 		cfg.ClientTimeout = def.ClientTimeout
 		updated = true
 	}
+	if cfg.CacheMaxEntries == 0 {
+		cfg.CacheMaxEntries = def.CacheMaxEntries
+		updated = true
+	}
 
 	// --- Slice ---
 	if len(cfg.BlacklistedBinaries) == 0 {
 		cfg.BlacklistedBinaries = def.BlacklistedBinaries
 		updated = true
 	}
+	if len(cfg.RefusalPhrases) == 0 {
+		cfg.RefusalPhrases = def.RefusalPhrases
+		updated = true
+	}
+	if cfg.AutoApproveReasons == nil {
+		cfg.AutoApproveReasons = def.AutoApproveReasons
+		updated = true
+	}
+	if cfg.TrustedDirs == nil {
+		cfg.TrustedDirs = def.TrustedDirs
+		updated = true
+	}
+	if cfg.FallbackProviders == nil {
+		cfg.FallbackProviders = def.FallbackProviders
+		updated = true
+	}
+	if cfg.FewShotExamples == nil {
+		cfg.FewShotExamples = def.FewShotExamples
+		updated = true
+	}
+	if cfg.ProbeTools == nil {
+		cfg.ProbeTools = def.ProbeTools
+		updated = true
+	}
+	if cfg.AllowlistedBinaries == nil {
+		cfg.AllowlistedBinaries = def.AllowlistedBinaries
+		updated = true
+	}
+	if cfg.CustomRiskPatterns == nil {
+		cfg.CustomRiskPatterns = def.CustomRiskPatterns
+		updated = true
+	}
 
-	// --- Automatic new-field detection ---
-	defMap := structToMap(def)
-	for k := range defMap {
-		if _, ok := raw[k]; !ok {
-			updated = true
-			break
+	// --- Map ---
+	if cfg.Templates == nil {
+		cfg.Templates = def.Templates
+		updated = true
+	}
+	if cfg.CustomHeaders == nil {
+		cfg.CustomHeaders = def.CustomHeaders
+		updated = true
+	}
+	if cfg.ShellInstructions == nil {
+		cfg.ShellInstructions = def.ShellInstructions
+		updated = true
+	}
+	if cfg.DeprecatedModels == nil {
+		cfg.DeprecatedModels = def.DeprecatedModels
+		updated = true
+	}
+	if cfg.ProviderPriority == nil {
+		cfg.ProviderPriority = def.ProviderPriority
+		updated = true
+	}
+	if cfg.LocalHeaders == nil {
+		cfg.LocalHeaders = def.LocalHeaders
+		updated = true
+	}
+	if cfg.ProviderTimeouts == nil {
+		cfg.ProviderTimeouts = def.ProviderTimeouts
+		updated = true
+	}
+
+	return updated
+}
+
+// fillZeroableDefaults sets the fields whose zero value is a valid setting
+// when raw lacks their key: the bools that default to true, and max_retries,
+// where 0 means no retries. Unlike other fields they cannot be patched by
+// fillDefaults, because a zero the user chose must survive.
+func fillZeroableDefaults(cfg *Config, raw map[string]any) {
+	def := defaultConfig()
+	if _, ok := raw["cache_enabled"]; !ok {
+		cfg.CacheEnabled = true
+	}
+	if _, ok := raw["audit_enabled"]; !ok {
+		cfg.AuditEnabled = true
+	}
+	if _, ok := raw["max_retries"]; !ok {
+		cfg.MaxRetries = def.MaxRetries
+	}
+}
+
+// LoadReader reads a config from r, e.g. JSON piped to stdin. Missing fields
+// get their defaults, but unlike Load nothing is ever written back to disk.
+func LoadReader(r io.Reader) (*Config, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	fillZeroableDefaults(&cfg, raw)
+	fillDefaults(&cfg)
+	return &cfg, nil
+}
+
+// StdinPath is the config path that means "read the config from stdin".
+const StdinPath = "-"
+
+// LoadFiles loads the first path like Load and overlays every following file on top.
+// Non-empty fields in later files win, so a shared base can be combined with
+// machine-specific overrides. With no paths it loads the default config.
+// A StdinPath entry is read from stdin and never saved. Finally ONELINER_<KEY>
+// environment variables override single fields, so precedence is
+// defaults < files < environment.
+func LoadFiles(paths []string) (*Config, error) {
+	cfg, _, err := LoadFilesWithSources(paths)
+	return cfg, err
+}
+
+// Sources maps a config key (its json tag) to where its effective value came
+// from: "default", the file (or "stdin") that set it, or "$ONELINER_<KEY>".
+type Sources map[string]string
+
+// SourceDefault marks a value that is the built-in default.
+const SourceDefault = "default"
+
+// LoadFilesWithSources is LoadFiles that also reports the source of every
+// value. A field of the first file counts as a default while it still equals
+// the built-in default, since Load writes every missing default into the file.
+func LoadFilesWithSources(paths []string) (*Config, Sources, error) {
+	first := ""
+	if len(paths) > 0 {
+		first = paths[0]
+	}
+
+	var cfg *Config
+	var err error
+	if first == StdinPath {
+		cfg, err = LoadReader(os.Stdin)
+	} else {
+		cfg, err = Load(first)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sources := make(Sources)
+	def := defaultConfig()
+	dv := reflect.ValueOf(def)
+	cv := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < cv.NumField(); i++ {
+		source := sourceName(first)
+		if reflect.DeepEqual(cv.Field(i).Interface(), dv.Field(i).Interface()) {
+			source = SourceDefault
 		}
+		sources[jsonKey(cv.Type().Field(i))] = source
 	}
 
-	// Save back if updated or new fields detected.
-	if updated {
-		if err := Save(path, &cfg); err != nil {
-			return nil, fmt.Errorf("failed to update config: %w", err)
+	for _, path := range paths[min(1, len(paths)):] {
+		var data []byte
+		if path == StdinPath {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(resolvePath(path))
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+		}
+
+		var overlay Config
+		if err := json.Unmarshal(data, &overlay); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+
+		for _, key := range merge(cfg, &overlay) {
+			sources[key] = sourceName(path)
 		}
 	}
 
-	return &cfg, nil
+	keys, err := applyEnv(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, key := range keys {
+		sources[key] = "$" + EnvName(key)
+	}
+
+	if err := validateRiskPatterns(cfg.CustomRiskPatterns); err != nil {
+		return nil, nil, fmt.Errorf("%w (set in %s)", err, sources["custom_risk_patterns"])
+	}
+	if level := cfg.MaxAutoRiskLevel; level != "" && !slices.Contains(RiskPatternLevels, strings.ToLower(strings.TrimSpace(level))) {
+		return nil, nil, fmt.Errorf("max_auto_risk_level must be one of %s, got %q (set in %s)", strings.Join(RiskPatternLevels, ", "), level, sources["max_auto_risk_level"])
+	}
+
+	return cfg, sources, nil
+}
+
+// sourceName describes a config path for Sources.
+func sourceName(path string) string {
+	if path == StdinPath {
+		return "stdin"
+	}
+	return resolvePath(path)
+}
+
+func jsonKey(field reflect.StructField) string {
+	key, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	return key
+}
+
+// apiKeyEnvVars lists the provider-specific variables checked after ONELINER_API_KEY.
+var apiKeyEnvVars = map[string][]string{
+	"openai":  {"OPENAI_API_KEY"},
+	"claude":  {"ANTHROPIC_API_KEY"},
+	"mistral": {"MISTRAL_API_KEY"},
+	"cohere":  {"COHERE_API_KEY", "CO_API_KEY"},
+	"gemini":  {"GEMINI_API_KEY", "GOOGLE_API_KEY"},
+}
+
+// APIKeyFromEnv looks up an API key for provider in the environment and returns
+// it with the name of the variable it came from. It is only meant as a fallback
+// for a blank api_key and is never written back to the config file.
+func APIKeyFromEnv(provider string) (key, source string) {
+	if v := strings.TrimSpace(os.Getenv("ONELINER_API_KEY")); v != "" {
+		return v, "ONELINER_API_KEY"
+	}
+	return ProviderAPIKeyFromEnv(provider)
+}
+
+// ProviderAPIKeyFromEnv is APIKeyFromEnv without ONELINER_API_KEY, which
+// belongs to the configured llm_api. Use it for any other provider, e.g. a
+// fallback, so it is never sent the primary provider's key.
+func ProviderAPIKeyFromEnv(provider string) (key, source string) {
+	for _, name := range apiKeyEnvVars[provider] {
+		if v := strings.TrimSpace(os.Getenv(name)); v != "" {
+			return v, name
+		}
+	}
+	return "", ""
 }
 
 func Save(path string, cfg *Config) error {
@@ -127,7 +463,34 @@ func Save(path string, cfg *Config) error {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	return os.WriteFile(path, data, 0600)
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic replaces path with data via a temp file in the same
+// directory and a rename, so a crash or a concurrent 'config set' never
+// leaves a truncated config behind; of two racing writers the last one wins.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	// a no-op once the rename succeeded
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
 }
 
 func createDefault(path string) error {
@@ -143,7 +506,13 @@ func createDefault(path string) error {
 		return err
 	}
 
-	return os.WriteFile(path, data, 0600)
+	return writeFileAtomic(path, data)
+}
+
+// Default returns a fresh copy of the built-in default config.
+func Default() *Config {
+	def := defaultConfig()
+	return &def
 }
 
 func defaultConfig() Config {
@@ -156,10 +525,55 @@ func defaultConfig() Config {
 		ClaudeMaxTokens:  1024,
 		RequestTimeout:   60,
 		ClientTimeout:    65,
+		MaxRetries:       2, // 0 or -1 disables retries
+		RiskDisplay:      "full",
+		CacheMaxEntries:  500, // set to -1 to keep every entry
+		CacheEnabled:     true,
+		AuditLog:         "~/.local/share/oneliner/audit.log",
+		AuditEnabled:     true,
 		BlacklistedBinaries: []string{
 			"rm", "dd", "mkfs", "fdisk", "parted",
 			"shred", "curl", "wget", "nc", "ncat",
 		},
+		Templates:          map[string]string{},
+		CustomHeaders:      map[string]string{},
+		AutoApproveReasons: []string{},
+		ShellInstructions:  map[string]string{},
+		TrustedDirs:        []string{},
+		FallbackProviders:  []string{},
+		FewShotExamples:    []FewShotExample{},
+		ProviderPriority:   map[string]int{},
+		LocalHeaders:       map[string]string{},
+		ProviderTimeouts:   map[string]int{},
+		// optional tools the model tends to assume; an empty list skips the probe
+		ProbeTools: []string{
+			"rg", "fd", "fdfind", "jq", "yq", "fzf", "bat", "eza",
+			"gawk", "parallel", "rsync", "git", "docker",
+		},
+		// binaries exempt from blacklisted_binaries; the other checks still apply
+		AllowlistedBinaries: []string{},
+		CustomRiskPatterns:  []RiskPattern{},
+		// retired model → suggested replacement; extend it as providers sunset models
+		DeprecatedModels: map[string]string{
+			"gpt-3.5-turbo":              "gpt-4o-mini",
+			"gpt-4":                      "gpt-4o",
+			"gpt-4-32k":                  "gpt-4o",
+			"gpt-4-vision-preview":       "gpt-4o",
+			"text-davinci-003":           "gpt-4o-mini",
+			"claude-instant-1.2":         "claude-sonnet-4-5-20250929",
+			"claude-2.0":                 "claude-sonnet-4-5-20250929",
+			"claude-2.1":                 "claude-sonnet-4-5-20250929",
+			"claude-3-sonnet-20240229":   "claude-sonnet-4-5-20250929",
+			"claude-3-opus-20240229":     "claude-opus-4-1-20250805",
+			"claude-3-5-sonnet-20240620": "claude-sonnet-4-5-20250929",
+			"claude-3-5-sonnet-20241022": "claude-sonnet-4-5-20250929",
+			"gemini-1.0-pro":             "gemini-1.5-flash",
+		},
+		RefusalPhrases: []string{
+			"i can't", "i cannot", "i won't", "i will not",
+			"i'm sorry", "i am sorry", "sorry,", "i apologize",
+			"i'm unable", "i am unable", "i'm not able", "as an ai",
+		},
 	}
 }
 
@@ -178,6 +592,12 @@ func detectDefaultShell() string {
 	}
 }
 
+// DefaultPath returns the config file used when no path is given,
+// ~/.config/oneliner/config.json.
+func DefaultPath() string {
+	return resolvePath("")
+}
+
 func resolvePath(customPath string) string {
 	if customPath != "" {
 		return customPath
@@ -190,6 +610,20 @@ func resolvePath(customPath string) string {
 	return filepath.Join(home, ".config", "oneliner", "config.json")
 }
 
+// merge copies every non-zero field of src onto dst and returns the keys it set.
+func merge(dst, src *Config) []string {
+	var keys []string
+	dv := reflect.ValueOf(dst).Elem()
+	sv := reflect.ValueOf(src).Elem()
+	for i := 0; i < sv.NumField(); i++ {
+		if f := sv.Field(i); !f.IsZero() {
+			dv.Field(i).Set(f)
+			keys = append(keys, jsonKey(sv.Type().Field(i)))
+		}
+	}
+	return keys
+}
+
 // --- helper to convert struct -> map[string]any for auto field detection
 func structToMap(cfg Config) map[string]any {
 	data, _ := json.Marshal(cfg)
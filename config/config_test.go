@@ -0,0 +1,323 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// clearEnv unsets every ONELINER_ variable for the test, so the developer's
+// own overrides do not leak into it.
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(name, envPrefix) {
+			os.Unsetenv(name)
+			t.Cleanup(func() { os.Setenv(name, value) })
+		}
+	}
+}
+
+// writeConfig writes a JSON config file named name into dir.
+func writeConfig(t *testing.T, dir, name, data string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFilesMergeOrder(t *testing.T) {
+	clearEnv(t)
+	files := map[string]string{
+		"base":  `{"llm_api": "openai", "api_key": "base-key", "model": "gpt-4o", "request_timeout": 30}`,
+		"work":  `{"api_key": "work-key", "model": "gpt-4o-mini"}`,
+		"local": `{"model": "local-model", "api_key": ""}`,
+	}
+
+	tests := []struct {
+		name      string
+		files     []string
+		model     string
+		apiKey    string
+		keySource string
+	}{
+		{"single file", []string{"base"}, "gpt-4o", "base-key", "base"},
+		{"later file wins", []string{"base", "work"}, "gpt-4o-mini", "work-key", "work"},
+		{"order matters", []string{"work", "base"}, "gpt-4o", "base-key", "base"},
+		{"empty fields keep earlier values", []string{"base", "work", "local"}, "local-model", "work-key", "work"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// fresh files each time: Load writes missing defaults into the first one
+			dir := t.TempDir()
+			paths := make(map[string]string)
+			var list []string
+			for _, name := range tt.files {
+				paths[name] = writeConfig(t, dir, name+".json", files[name])
+				list = append(list, paths[name])
+			}
+
+			cfg, sources, err := LoadFilesWithSources(list)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Model != tt.model || cfg.APIKey != tt.apiKey {
+				t.Errorf("model %q, api_key %q; want %q, %q", cfg.Model, cfg.APIKey, tt.model, tt.apiKey)
+			}
+			if sources["api_key"] != paths[tt.keySource] {
+				t.Errorf("api_key source %q, want %q", sources["api_key"], paths[tt.keySource])
+			}
+			if tt.files[0] == "base" && cfg.RequestTimeout != 30 {
+				t.Errorf("request_timeout %d, want 30 from base", cfg.RequestTimeout)
+			}
+		})
+	}
+}
+
+func TestLoadFilesEnvWins(t *testing.T) {
+	clearEnv(t)
+	dir := t.TempDir()
+	base := writeConfig(t, dir, "base.json", `{"api_key": "base-key"}`)
+	work := writeConfig(t, dir, "work.json", `{"api_key": "work-key"}`)
+	t.Setenv("ONELINER_API_KEY", "env-key")
+
+	cfg, sources, err := LoadFilesWithSources([]string{base, work})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.APIKey != "env-key" || sources["api_key"] != "$ONELINER_API_KEY" {
+		t.Errorf("api_key %q from %q, want env-key from $ONELINER_API_KEY", cfg.APIKey, sources["api_key"])
+	}
+}
+
+func TestSaveConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := Save(path, Default()); err != nil {
+		t.Fatal(err)
+	}
+
+	const writers = 40
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cfg := Default()
+			cfg.Model = fmt.Sprintf("model-%d", i)
+			// vary the size so a torn write would show as invalid JSON
+			cfg.BlacklistedBinaries = make([]string, i+1)
+			for j := range cfg.BlacklistedBinaries {
+				cfg.BlacklistedBinaries[j] = fmt.Sprintf("bin-%d", j)
+			}
+			if err := Save(path, cfg); err != nil {
+				t.Errorf("Save %d: %v", i, err)
+			}
+		}(i)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	// read while the writers race: every read must see a complete file
+	for reading := true; reading; {
+		select {
+		case <-done:
+			reading = false
+		default:
+		}
+		data, err := os.ReadFile(path)
+		if err == nil {
+			var cfg Config
+			err = json.Unmarshal(data, &cfg)
+		}
+		if err != nil {
+			<-done
+			t.Fatalf("config unreadable during concurrent saves: %v", err)
+		}
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load after concurrent saves: %v", err)
+	}
+	var n int
+	if _, err := fmt.Sscanf(cfg.Model, "model-%d", &n); err != nil || len(cfg.BlacklistedBinaries) != n+1 {
+		t.Errorf("final config mixes writers: model %q with %d blacklisted binaries", cfg.Model, len(cfg.BlacklistedBinaries))
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("temp files left behind: %q", names)
+	}
+}
+
+// pipeStdin replaces os.Stdin with a pipe carrying data for the test.
+func pipeStdin(t *testing.T, data string) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		w.WriteString(data)
+		w.Close()
+	}()
+
+	stdin := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() {
+		os.Stdin = stdin
+		r.Close()
+	})
+}
+
+func TestLoadReader(t *testing.T) {
+	cfg, err := LoadReader(strings.NewReader(`{"llm_api": "claude", "model": "claude-sonnet", "audit_enabled": false}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LLMAPI != "claude" || cfg.Model != "claude-sonnet" {
+		t.Errorf("llm_api %q, model %q; want claude, claude-sonnet", cfg.LLMAPI, cfg.Model)
+	}
+	if cfg.RequestTimeout != Default().RequestTimeout || len(cfg.BlacklistedBinaries) == 0 {
+		t.Error("missing fields did not get their defaults")
+	}
+	if !cfg.CacheEnabled || cfg.AuditEnabled {
+		t.Errorf("cache_enabled %v, audit_enabled %v; want the missing one true and the explicit false kept", cfg.CacheEnabled, cfg.AuditEnabled)
+	}
+
+	if _, err := LoadReader(strings.NewReader(`{"model": `)); err == nil {
+		t.Error("LoadReader accepted truncated JSON")
+	}
+}
+
+func TestLoadFilesFromStdin(t *testing.T) {
+	clearEnv(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	pipeStdin(t, `{"llm_api": "local", "model": "llama3", "api_key": "stdin-key"}`)
+
+	cfg, sources, err := LoadFilesWithSources([]string{StdinPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LLMAPI != "local" || cfg.Model != "llama3" || cfg.APIKey != "stdin-key" {
+		t.Errorf("got llm_api %q, model %q, api_key %q from stdin", cfg.LLMAPI, cfg.Model, cfg.APIKey)
+	}
+	if sources["model"] != "stdin" {
+		t.Errorf("model source %q, want stdin", sources["model"])
+	}
+
+	// an ephemeral config is never written anywhere
+	if _, err := os.Stat(DefaultPath()); !os.IsNotExist(err) {
+		t.Errorf("stdin config was saved to %s", DefaultPath())
+	}
+}
+
+func TestLoadFilesStdinOverlay(t *testing.T) {
+	clearEnv(t)
+	base := writeConfig(t, t.TempDir(), "base.json", `{"llm_api": "openai", "api_key": "file-key", "model": "gpt-4o"}`)
+	pipeStdin(t, `{"api_key": "stdin-key"}`)
+
+	cfg, err := LoadFiles([]string{base, StdinPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.APIKey != "stdin-key" || cfg.Model != "gpt-4o" {
+		t.Errorf("api_key %q, model %q; want stdin-key over the file, gpt-4o from it", cfg.APIKey, cfg.Model)
+	}
+
+	after, err := os.ReadFile(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(after), "stdin-key") {
+		t.Errorf("secret from stdin written to %s", base)
+	}
+}
+
+func TestAPIKeyFromEnv(t *testing.T) {
+	t.Setenv("ONELINER_API_KEY", "sk-primary")
+	t.Setenv("ANTHROPIC_API_KEY", "sk-ant")
+	t.Setenv("GEMINI_API_KEY", "")
+	t.Setenv("GOOGLE_API_KEY", "g-key")
+
+	if key, source := APIKeyFromEnv("claude"); key != "sk-primary" || source != "ONELINER_API_KEY" {
+		t.Errorf("APIKeyFromEnv(claude) = %q from %s, want ONELINER_API_KEY first", key, source)
+	}
+
+	tests := []struct {
+		provider string
+		key      string
+		source   string
+	}{
+		{"claude", "sk-ant", "ANTHROPIC_API_KEY"},
+		{"gemini", "g-key", "GOOGLE_API_KEY"},
+		{"local", "", ""},
+	}
+	for _, tt := range tests {
+		if key, source := ProviderAPIKeyFromEnv(tt.provider); key != tt.key || source != tt.source {
+			t.Errorf("ProviderAPIKeyFromEnv(%s) = %q from %q, want %q from %q", tt.provider, key, source, tt.key, tt.source)
+		}
+	}
+}
+
+func TestLoadKeepsZeroMaxRetries(t *testing.T) {
+	clearEnv(t)
+	dir := t.TempDir()
+
+	tests := []struct {
+		name string
+		data string
+		want int
+	}{
+		{"zero", `{"max_retries": 0}`, 0},
+		{"disabled", `{"max_retries": -1}`, -1},
+		{"set", `{"max_retries": 5}`, 5},
+		{"missing", `{"model": "gpt-4o"}`, Default().MaxRetries},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, dir, tt.name+".json", tt.data)
+
+			// the second Load reads what the first one saved back
+			for range 2 {
+				cfg, err := Load(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if cfg.MaxRetries != tt.want {
+					t.Fatalf("max_retries = %d, want %d", cfg.MaxRetries, tt.want)
+				}
+			}
+		})
+	}
+
+	cfg, err := LoadReader(strings.NewReader(`{"max_retries": 0}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MaxRetries != 0 {
+		t.Errorf("LoadReader max_retries = %d, want 0", cfg.MaxRetries)
+	}
+}
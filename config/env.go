@@ -0,0 +1,99 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// envPrefix starts the variables that override config fields, e.g.
+// ONELINER_MODEL for "model" or ONELINER_CLAUDE_MAX_TOKENS for "claude_max_tokens".
+const envPrefix = "ONELINER_"
+
+// EnvName returns the environment variable that overrides the config key.
+func EnvName(key string) string {
+	return envPrefix + strings.ToUpper(key)
+}
+
+// applyEnv overrides fields of cfg with any ONELINER_<KEY> variables that are
+// set and returns the keys it changed. Lists of strings are comma-separated;
+// other lists and maps are JSON. Values are never written back to the config file.
+func applyEnv(cfg *Config) ([]string, error) {
+	var keys []string
+	v := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		key := jsonKey(v.Type().Field(i))
+		name := EnvName(key)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setFromEnv(v.Field(i), strings.TrimSpace(value)); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func setFromEnv(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		field.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+		field.SetBool(b)
+	case reflect.Ptr:
+		// optional floats such as temperature; empty clears them
+		if value == "" {
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		}
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		field.Set(reflect.ValueOf(&f))
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			// lists of objects, such as few_shot_examples, are JSON arrays
+			items := reflect.New(field.Type())
+			if err := json.Unmarshal([]byte(value), items.Interface()); err != nil {
+				return fmt.Errorf("expected a JSON array: %w", err)
+			}
+			field.Set(items.Elem())
+			return nil
+		}
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		if items == nil {
+			items = []string{}
+		}
+		field.Set(reflect.ValueOf(items))
+	case reflect.Map:
+		m := reflect.New(field.Type())
+		if err := json.Unmarshal([]byte(value), m.Interface()); err != nil {
+			return fmt.Errorf("expected a JSON object: %w", err)
+		}
+		field.Set(m.Elem())
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Kind())
+	}
+	return nil
+}
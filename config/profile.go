@@ -0,0 +1,73 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ProfilePath returns the file of the named profile, config.<name>.json next
+// to the default config file.
+func ProfilePath(name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid profile name %q", name)
+	}
+	return filepath.Join(filepath.Dir(resolvePath("")), "config."+name+".json"), nil
+}
+
+// ResolveProfile returns the path of an existing profile, so a typo is
+// reported instead of silently creating a new profile full of defaults.
+func ResolveProfile(name string) (string, error) {
+	path, err := ProfilePath(name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("profile %q not found (expected %s)", name, path)
+		}
+		return "", fmt.Errorf("failed to read profile %q: %w", name, err)
+	}
+	return path, nil
+}
+
+// Profiles lists the names of the saved profiles, sorted.
+func Profiles() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(resolvePath("")), "config.*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(matches))
+	for _, match := range matches {
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(match), "config."), ".json")
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// UseProfile makes the named profile the active config by copying it over
+// the default config file. A copy rather than a symlink works on every OS
+// and leaves the profile untouched by later 'config set' calls.
+func UseProfile(name string) error {
+	path, err := ResolveProfile(name)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read profile %q: %w", name, err)
+	}
+	if _, err := LoadReader(bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("profile %q: %w", name, err)
+	}
+
+	return writeFileAtomic(resolvePath(""), data)
+}
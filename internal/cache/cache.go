@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 )
@@ -15,17 +16,34 @@ type Cache struct {
 	path string
 	mu   sync.RWMutex
 	data map[string]cacheEntry
+	// ttl is how long an entry stays valid; 0 means entries never expire.
+	ttl time.Duration
+	// maxEntries caps the number of entries; 0 or less means no limit.
+	maxEntries int
+	now        func() time.Time
 }
 
 type cacheEntry struct {
 	Command   string    `json:"command"`
 	Timestamp time.Time `json:"timestamp"`
+	// UseCount is how many times the command was produced, including cache
+	// hits. Entries written before it existed decode as 0.
+	UseCount int `json:"use_count,omitempty"`
+	// LastUsed is when the entry was last served from the cache. It is zero
+	// until the first cache hit, in which case Timestamp counts instead.
+	LastUsed time.Time `json:"last_used,omitzero"`
 }
 
-func New(path string) (*Cache, error) {
+// New opens the cache at path. Entries older than ttl are treated as missing
+// and removed when next read; a ttl of 0 keeps them forever. Once more than
+// maxEntries are stored, Set evicts the least recently used ones.
+func New(path string, ttl time.Duration, maxEntries int) (*Cache, error) {
 	c := &Cache{
-		path: path,
-		data: make(map[string]cacheEntry),
+		path:       path,
+		data:       make(map[string]cacheEntry),
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
 	}
 	if err := c.load(); err != nil {
 		return nil, fmt.Errorf("loading cache: %w", err)
@@ -62,7 +80,7 @@ func (c *Cache) load() error {
 		for k, v := range legacyData {
 			c.data[k] = cacheEntry{
 				Command:   v,
-				Timestamp: time.Now(), // Use current time for legacy entries
+				Timestamp: c.now(), // Use current time for legacy entries
 			}
 		}
 
@@ -81,8 +99,11 @@ func (c *Cache) save() error {
 }
 
 func (c *Cache) saveNoLock() error {
+	return c.write(c.data)
+}
 
-	data, err := json.MarshalIndent(c.data, "", "  ")
+func (c *Cache) write(entries map[string]cacheEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
 	if err != nil {
 		return fmt.Errorf("encoding cache: %w", err)
 	}
@@ -105,58 +126,130 @@ func (c *Cache) saveNoLock() error {
 	return nil
 }
 
+// snapshotNoLock copies the entries so they can be written without holding the lock.
+func (c *Cache) snapshotNoLock() map[string]cacheEntry {
+	dataCopy := make(map[string]cacheEntry, len(c.data))
+	for k, v := range c.data {
+		dataCopy[k] = v
+	}
+	return dataCopy
+}
+
 func (c *Cache) Get(key string) (string, bool) {
 	c.mu.RLock()
-	defer c.mu.RUnlock()
 	entry, ok := c.data[key]
-	return entry.Command, ok
+	c.mu.RUnlock()
+	if !ok {
+		return "", false
+	}
+
+	if c.expired(entry) {
+		// best effort: a failed write only means the entry is dropped again next time
+		_ = c.remove(key)
+		return "", false
+	}
+	return entry.Command, true
+}
+
+func (c *Cache) expired(entry cacheEntry) bool {
+	return c.ttl > 0 && c.now().Sub(entry.Timestamp) > c.ttl
+}
+
+// remove deletes an expired entry, rechecking under the write lock in case it
+// was refreshed in the meantime.
+func (c *Cache) remove(key string) error {
+	c.mu.Lock()
+	entry, ok := c.data[key]
+	if !ok || !c.expired(entry) {
+		c.mu.Unlock()
+		return nil
+	}
+	delete(c.data, key)
+	dataCopy := c.snapshotNoLock()
+	c.mu.Unlock()
+
+	return c.write(dataCopy)
 }
 
 func (c *Cache) Set(key, value string) error {
 	c.mu.Lock()
 	c.data[key] = cacheEntry{
 		Command:   value,
-		Timestamp: time.Now(),
-	}
-	dataCopy := make(map[string]cacheEntry, len(c.data))
-	for k, v := range c.data {
-		dataCopy[k] = v
+		Timestamp: c.now(),
+		UseCount:  1,
 	}
+	c.evictNoLock()
+	dataCopy := c.snapshotNoLock()
 	c.mu.Unlock()
 
-	data, err := json.MarshalIndent(dataCopy, "", "  ")
-	if err != nil {
-		return fmt.Errorf("encoding cache: %w", err)
+	return c.write(dataCopy)
+}
+
+// evictNoLock drops the least recently used entries until at most maxEntries remain.
+func (c *Cache) evictNoLock() {
+	excess := len(c.data) - c.maxEntries
+	if c.maxEntries <= 0 || excess <= 0 {
+		return
 	}
 
-	dir := filepath.Dir(c.path)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("creating cache directory: %w", err)
+	keys := make([]string, 0, len(c.data))
+	for k := range c.data {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return lastUsed(c.data[keys[i]]).Before(lastUsed(c.data[keys[j]]))
+	})
+	for _, k := range keys[:excess] {
+		delete(c.data, k)
 	}
+}
 
-	tempPath := c.path + ".tmp"
-	if err := os.WriteFile(tempPath, data, 0600); err != nil {
-		return fmt.Errorf("writing temp file: %w", err)
+func lastUsed(entry cacheEntry) time.Time {
+	if entry.LastUsed.After(entry.Timestamp) {
+		return entry.LastUsed
 	}
-	if err := os.Rename(tempPath, c.path); err != nil {
-		os.Remove(tempPath)
-		return fmt.Errorf("renaming temp file: %w", err)
+	return entry.Timestamp
+}
+
+// RecordUse bumps the use count and last-used time of a cached entry after a cache hit.
+func (c *Cache) RecordUse(key string) error {
+	c.mu.Lock()
+	entry, ok := c.data[key]
+	if !ok {
+		c.mu.Unlock()
+		return nil
 	}
-	return nil
+	// entries from before use counts were tracked were produced at least once
+	entry.UseCount = max(entry.UseCount, 1) + 1
+	entry.LastUsed = c.now()
+	c.data[key] = entry
+	dataCopy := c.snapshotNoLock()
+	c.mu.Unlock()
+
+	return c.write(dataCopy)
 }
 
-func HashQuery(query, osys, cwd, username, shell string, explain, breakdown bool) string {
+// HashQuery derives the cache key for a query. The provider and model are part
+// of the key so switching backends never serves another model's answer.
+// Modifiers name any extra options (such as "annotate") that change the
+// generated answer; they are hashed in order.
+func HashQuery(query, osys, cwd, username, shell, provider, model string, explain, breakdown bool, modifiers ...string) string {
 	h := sha256.New()
 	h.Write([]byte(query))
 	h.Write([]byte(osys))
 	h.Write([]byte(cwd))
 	h.Write([]byte(username))
 	h.Write([]byte(shell))
+	h.Write([]byte(provider))
+	h.Write([]byte(model))
 	if explain {
 		h.Write([]byte("explain"))
 	}
 	if breakdown {
 		h.Write([]byte("breakdown"))
 	}
+	for _, m := range modifiers {
+		h.Write([]byte(m))
+	}
 	return hex.EncodeToString(h.Sum(nil))
 }
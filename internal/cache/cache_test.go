@@ -0,0 +1,218 @@
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// clock is a settable time source for Cache.now.
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+// newTestCache opens a cache in a temp dir with its clock under test control.
+func newTestCache(t *testing.T, ttl time.Duration, maxEntries int) (*Cache, *clock) {
+	t.Helper()
+	c, err := New(filepath.Join(t.TempDir(), "commands.json"), ttl, maxEntries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clk := &clock{time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	c.now = clk.now
+	return c, clk
+}
+
+func TestGetExpiresAfterTTL(t *testing.T) {
+	c, clk := newTestCache(t, 24*time.Hour, 0)
+	if err := c.Set("k", "ls -la"); err != nil {
+		t.Fatal(err)
+	}
+
+	clk.t = clk.t.Add(23 * time.Hour)
+	if _, ok := c.Get("k"); !ok {
+		t.Fatal("entry missing before its TTL ran out")
+	}
+
+	clk.t = clk.t.Add(2 * time.Hour)
+	if _, ok := c.Get("k"); ok {
+		t.Fatal("entry still served after its TTL ran out")
+	}
+
+	// the expired entry is dropped from disk as well
+	reopened, err := New(c.path, 24*time.Hour, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := reopened.data["k"]; ok {
+		t.Error("expired entry still on disk")
+	}
+}
+
+func TestGetBackdatedEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "commands.json")
+	entries := map[string]cacheEntry{
+		"old":   {Command: "ls", Timestamp: time.Now().Add(-48 * time.Hour)},
+		"fresh": {Command: "pwd", Timestamp: time.Now().Add(-time.Hour)},
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := New(path, 24*time.Hour, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("old"); ok {
+		t.Error("backdated entry older than the TTL was served")
+	}
+	if got, ok := c.Get("fresh"); !ok || got != "pwd" {
+		t.Errorf("Get(fresh) = %q, %v; want pwd, true", got, ok)
+	}
+
+	forever, err := New(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forever.data["old"] = entries["old"]
+	if _, ok := forever.Get("old"); !ok {
+		t.Error("a TTL of 0 expired an entry")
+	}
+}
+
+func TestSetEvictsLeastRecentlyUsed(t *testing.T) {
+	const maxEntries = 3
+	c, clk := newTestCache(t, 0, maxEntries)
+
+	for _, k := range []string{"a", "b", "c"} {
+		clk.t = clk.t.Add(time.Minute)
+		if err := c.Set(k, "echo "+k); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// N+1: the oldest entry goes
+	clk.t = clk.t.Add(time.Minute)
+	if err := c.Set("d", "echo d"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Error("oldest entry a survived the N+1th Set")
+	}
+	for _, k := range []string{"b", "c", "d"} {
+		if _, ok := c.Get(k); !ok {
+			t.Errorf("entry %s evicted, want it kept", k)
+		}
+	}
+
+	// a cache hit makes b the most recently used, so c goes next
+	clk.t = clk.t.Add(time.Minute)
+	if err := c.RecordUse("b"); err != nil {
+		t.Fatal(err)
+	}
+	clk.t = clk.t.Add(time.Minute)
+	if err := c.Set("e", "echo e"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("c"); ok {
+		t.Error("least recently used entry c survived")
+	}
+	if _, ok := c.Get("b"); !ok {
+		t.Error("recently used entry b was evicted")
+	}
+
+	reopened, err := New(c.path, 0, maxEntries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reopened.data) != maxEntries {
+		t.Errorf("%d entries on disk, want %d", len(reopened.data), maxEntries)
+	}
+}
+
+func TestRecordUseCountsHits(t *testing.T) {
+	c, clk := newTestCache(t, 0, 0)
+	if err := c.Set("k", "ls -la"); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.data["k"].UseCount; got != 1 {
+		t.Fatalf("UseCount after Set = %d, want 1", got)
+	}
+
+	for i := 0; i < 3; i++ {
+		clk.t = clk.t.Add(time.Minute)
+		if _, ok := c.Get("k"); !ok {
+			t.Fatal("entry missing")
+		}
+		if err := c.RecordUse("k"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reopened, err := New(c.path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := reopened.data["k"]
+	if entry.UseCount != 4 {
+		t.Errorf("UseCount after three hits = %d, want 4", entry.UseCount)
+	}
+	if !entry.LastUsed.Equal(clk.t) {
+		t.Errorf("LastUsed = %v, want %v", entry.LastUsed, clk.t)
+	}
+
+	// entries written before use counts were tracked count as produced once
+	c.data["legacy"] = cacheEntry{Command: "pwd", Timestamp: clk.t}
+	if err := c.RecordUse("legacy"); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.data["legacy"].UseCount; got != 2 {
+		t.Errorf("UseCount of a legacy entry after one hit = %d, want 2", got)
+	}
+
+	if err := c.RecordUse("missing"); err != nil {
+		t.Errorf("RecordUse of a missing key: %v", err)
+	}
+	if _, ok := c.data["missing"]; ok {
+		t.Error("RecordUse created an entry")
+	}
+}
+
+func TestHashQueryByProviderAndModel(t *testing.T) {
+	hash := func(provider, model string) string {
+		return HashQuery("find large files", "linux", "/home/me", "me", "bash", provider, model, false, false)
+	}
+
+	gpt := hash("openai", "gpt-4o")
+	sonnet := hash("claude", "claude-sonnet")
+	mini := hash("openai", "gpt-4o-mini")
+	if gpt == sonnet || gpt == mini {
+		t.Fatalf("same query under different models hashed alike: %s %s %s", gpt, sonnet, mini)
+	}
+	if gpt != hash("openai", "gpt-4o") {
+		t.Fatal("HashQuery is not stable")
+	}
+
+	c, _ := newTestCache(t, 0, 0)
+	if err := c.Set(gpt, "find . -size +100M"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set(sonnet, "du -ah . | sort -rh | head"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := c.Get(gpt); got != "find . -size +100M" {
+		t.Errorf("gpt-4o entry = %q", got)
+	}
+	if got, _ := c.Get(sonnet); got != "du -ah . | sort -rh | head" {
+		t.Errorf("claude-sonnet entry = %q", got)
+	}
+	if _, ok := c.Get(mini); ok {
+		t.Error("gpt-4o-mini was served another model's answer")
+	}
+}
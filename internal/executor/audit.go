@@ -0,0 +1,86 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dorochadev/oneliner/config"
+)
+
+// auditEntry is one line of the audit log. Entries are only ever appended;
+// unlike the cache the log is never pruned.
+type auditEntry struct {
+	Time    time.Time `json:"time"`
+	Query   string    `json:"query,omitempty"`
+	Command string    `json:"command"`
+	Dir     string    `json:"dir,omitempty"`
+	Risk    string    `json:"risk"`
+	Reasons []string  `json:"reasons"`
+	Sudo    bool      `json:"sudo"`
+}
+
+// auditPath returns the configured audit_log path with ~ expanded.
+func auditPath(cfg *config.Config) (string, error) {
+	path := config.Default().AuditLog
+	if cfg != nil && strings.TrimSpace(cfg.AuditLog) != "" {
+		path = strings.TrimSpace(cfg.AuditLog)
+	}
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	return path, nil
+}
+
+// writeAudit appends a record of the command about to run to the audit log.
+// It is a no-op when audit_enabled is off or --no-audit was given.
+func writeAudit(command string, assessment RiskAssessment, cfg *config.Config, opts Options) error {
+	if opts.NoAudit || (cfg != nil && !cfg.AuditEnabled) {
+		return nil
+	}
+
+	path, err := auditPath(cfg)
+	if err != nil {
+		return err
+	}
+
+	entry := auditEntry{
+		Time:    time.Now().UTC(),
+		Query:   opts.Query,
+		Command: command,
+		Risk:    assessment.Level.String(),
+		Reasons: assessment.Reasons,
+		Sudo:    strings.HasPrefix(command, "sudo "),
+	}
+	if entry.Reasons == nil {
+		entry.Reasons = []string{}
+	}
+	if cwd, err := workDir(opts); err == nil {
+		entry.Dir = cwd
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,80 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// EditModel lets the user change a command before it runs: enter accepts the
+// text as it stands, esc or ctrl+c cancels.
+type EditModel struct {
+	textInput textinput.Model
+	// Command is the edited command once Accepted is set.
+	Command  string
+	Accepted bool
+}
+
+func NewEditModel(command string) EditModel {
+	ti := textinput.New()
+	ti.CharLimit = 0
+	ti.Width = 80
+	ti.SetValue(command)
+	ti.CursorEnd()
+	ti.Focus()
+
+	return EditModel{textInput: ti}
+}
+
+func (m EditModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m EditModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "ctrl+c", "esc":
+			return m, tea.Quit
+		case "enter":
+			if command := strings.TrimSpace(m.textInput.Value()); command != "" {
+				m.Command = command
+				m.Accepted = true
+			}
+			return m, tea.Quit
+		}
+	}
+
+	var cmd tea.Cmd
+	m.textInput, cmd = m.textInput.Update(msg)
+	return m, cmd
+}
+
+func (m EditModel) View() string {
+	if m.Accepted {
+		return ""
+	}
+	return fmt.Sprintf(
+		"\n%s\n%s\n\n%s\n",
+		cyanStyle.Render("Edit command:"),
+		m.textInput.View(),
+		dimStyle.Render("  enter run • esc cancel"),
+	)
+}
+
+// EditCommand shows command in an editable prompt and returns what the user
+// left in it. ok is false when they cancelled or cleared the line.
+func EditCommand(command string) (edited string, ok bool, err error) {
+	if !canPrompt() {
+		return "", false, errors.New("cannot edit the command without a terminal")
+	}
+
+	m, err := tea.NewProgram(NewEditModel(command)).Run()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to show edit prompt: %w", err)
+	}
+	result := m.(EditModel)
+	return result.Command, result.Accepted, nil
+}
@@ -1,12 +1,15 @@
 package executor
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
-	"runtime"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/briandowns/spinner"
@@ -14,6 +17,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/dorochadev/oneliner/config"
+	"golang.org/x/term"
 )
 
 var (
@@ -28,6 +32,37 @@ var (
 			Background(lipgloss.Color("0")).Padding(0, 1)
 )
 
+// Options controls how Execute confirms and runs a command.
+type Options struct {
+	// Sudo reports whether sudo was added on purpose via --sudo.
+	Sudo bool
+	// Quiet suppresses oneliner's own status lines. Command output is never suppressed.
+	Quiet bool
+	// Displayed is the command exactly as it was shown to the user. When set,
+	// Execute refuses to run anything else (apart from an explicit sudo prefix).
+	Displayed string
+	// DryRun assesses and prints the command exactly as it would run, without
+	// asking for consent or confirmation and without running it.
+	DryRun bool
+	// Query is the request that produced the command, recorded in the audit log.
+	Query string
+	// NoAudit skips the audit log for this run, as --no-audit does.
+	NoAudit bool
+	// AssumeYes answers the consent, risk and sudo prompts with yes. Risk
+	// reasons are still printed for the record.
+	AssumeYes bool
+	// Shell runs the command instead of sh (cmd on Windows), e.g. "zsh" or
+	// "pwsh", as --shell does.
+	Shell string
+	// Dir runs the command in this directory instead of the current one, as
+	// --cwd does.
+	Dir string
+	// Output also writes the command's stdout and stderr to this file, as
+	// --output does. An existing file is only replaced when Overwrite is set.
+	Output    string
+	Overwrite bool
+}
+
 type confirmModel struct {
 	textInput       textinput.Model
 	confirmed       bool
@@ -111,7 +146,7 @@ func runSudoAuth() error {
 	return nil
 }
 
-func printCommand(cmd string, withSudo bool) {
+func printCommand(cmd string, withSudo bool, sandbox []string, shell string) {
 	fmt.Println()
 	fmt.Print(dimStyle.Render("  "))
 	if withSudo {
@@ -121,29 +156,74 @@ func printCommand(cmd string, withSudo bool) {
 	fmt.Print(cyanStyle.Render("❯"))
 	fmt.Print(" ")
 	fmt.Println(whiteStyle.Render(cmd))
+	if sandbox != nil || shell != "" {
+		fmt.Println(dimStyle.Render("    via " + formatInvocation(shellArgv(sandbox, shell, cmd))))
+	}
 }
 
-func runCommand(trimmed string) error {
-	s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
-	s.Prefix = dimStyle.Render("  ◆ ")
-	s.Start()
-	startTime := time.Now()
+func runCommand(trimmed string, opts Options, sandbox []string) error {
+	// created only now, so a cancelled confirmation leaves an existing file alone
+	var output *os.File
+	if opts.Output != "" {
+		flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+		if opts.Overwrite {
+			flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		}
+		f, err := os.OpenFile(opts.Output, flags, 0600)
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("%s already exists (use --force to overwrite)", opts.Output)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer f.Close()
+		output = f
+	}
 
-	shell := "sh"
-	args := []string{"-c", trimmed}
-	if runtime.GOOS == "windows" {
-		shell = "cmd"
-		args = []string{"/C", trimmed}
+	var s *spinner.Spinner
+	if !opts.Quiet {
+		s = spinner.New(spinner.CharSets[9], 100*time.Millisecond)
+		s.Prefix = dimStyle.Render("  ◆ ")
+		s.Start()
 	}
+	startTime := time.Now()
 
-	cmd := exec.Command(shell, args...)
+	argv := shellArgv(sandbox, opts.Shell, trimmed)
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Dir = opts.Dir
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
+	if output != nil {
+		cmd.Stdout = io.MultiWriter(os.Stdout, output)
+		cmd.Stderr = io.MultiWriter(os.Stderr, output)
+	}
 	cmd.Stdin = os.Stdin
 
-	err := cmd.Run()
-	s.Stop()
+	err := runInGroup(cmd)
 	duration := time.Since(startTime)
+
+	if errors.Is(err, ErrInterrupted) {
+		if opts.Quiet {
+			return err
+		}
+		s.Stop()
+		fmt.Print("\r\033[K")
+		fmt.Println()
+		fmt.Print(cancelStyle.Render("  ✗ INTERRUPTED"))
+		fmt.Print(" ")
+		fmt.Println(dimStyle.Render(fmt.Sprintf("• stopped after %.1fs", duration.Seconds())))
+		fmt.Println()
+		return err
+	}
+
+	if opts.Quiet {
+		if err != nil {
+			return fmt.Errorf("command execution failed: %w", err)
+		}
+		return nil
+	}
+
+	s.Stop()
 	fmt.Print("\r\033[K") // Clear the spinner line
 
 	fmt.Println()
@@ -154,24 +234,120 @@ func runCommand(trimmed string) error {
 
 	fmt.Print(successStyle.Render("  ✓ SUCCESS"))
 	fmt.Print(" ")
-	fmt.Printf("%s\n", dimStyle.Render(fmt.Sprintf("• executed in %.1fs", duration.Seconds())))
+	status := fmt.Sprintf("• executed in %.1fs", duration.Seconds())
+	if output != nil {
+		status += " • output saved to " + opts.Output
+	}
+	fmt.Printf("%s\n", dimStyle.Render(status))
 	fmt.Println()
 
 	return nil
 }
 
-func ensureRunConsent() (bool, error) {
+// ErrNoTerminal is returned when a confirmation prompt is needed but stdin is
+// not a terminal, e.g. in a pipe or CI, where the prompt could never be answered.
+var ErrNoTerminal = errors.New("cannot ask for confirmation without a terminal; pass --yes to confirm non-interactively")
+
+// canPrompt reports whether stdin is a terminal the Bubble Tea prompts can use.
+func canPrompt() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// ErrRiskBlocked is returned when a command would run without anyone
+// confirming it, but its risk reaches the user's max_auto_risk_level.
+var ErrRiskBlocked = errors.New("refusing to run without confirmation")
+
+// ErrInterrupted is returned when the running command was stopped with Ctrl+C or SIGTERM.
+var ErrInterrupted = errors.New("interrupted")
+
+// killGracePeriod is how long an interrupted command may take to exit before
+// its process group is killed.
+const killGracePeriod = 3 * time.Second
+
+// runInGroup runs cmd in its own process group. SIGINT and SIGTERM received by
+// oneliner are forwarded to the whole group, so the command and anything it
+// spawned stop together instead of being left orphaned. A group still running
+// after killGracePeriod, or a second interrupt, gets SIGKILL.
+//
+// To check by hand: run `oneliner -r "sleep 300 | cat"`, press Ctrl+C (or
+// `kill -INT` oneliner from another terminal), and confirm with
+// `pgrep sleep` that nothing is left behind.
+func runInGroup(cmd *exec.Cmd) error {
+	restore := setProcessGroup(cmd)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	if err := cmd.Start(); err != nil {
+		restore()
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	interrupted := false
+	var killTimer <-chan time.Time
+	var err error
+	for waiting := true; waiting; {
+		select {
+		case sig := <-sigs:
+			if interrupted {
+				// a second Ctrl+C means stop now
+				killProcessGroup(cmd)
+				continue
+			}
+			interrupted = true
+			signalProcessGroup(cmd, sig)
+			killTimer = time.After(killGracePeriod)
+		case <-killTimer:
+			// the group ignored the signal; don't leave it running behind us
+			killProcessGroup(cmd)
+		case err = <-done:
+			waiting = false
+		}
+	}
+	restore()
+
+	if interrupted || killedBySignal(cmd.ProcessState) {
+		return ErrInterrupted
+	}
+	return err
+}
+
+// ConsentPath returns the file that records first-run consent for --run.
+func ConsentPath() (string, error) {
 	configDir, err := os.UserConfigDir()
 	if err != nil {
-		return false, fmt.Errorf("failed to locate user config dir: %w", err)
+		return "", fmt.Errorf("failed to locate user config dir: %w", err)
 	}
+	return filepath.Join(configDir, "oneliner", "consent_run.txt"), nil
+}
 
-	consentFile := filepath.Join(configDir, "oneliner", "consent_run.txt")
+func ensureRunConsent(assumeYes bool) (bool, error) {
+	consentFile, err := ConsentPath()
+	if err != nil {
+		return false, err
+	}
 
 	if _, err := os.Stat(consentFile); err == nil {
 		return true, nil
 	}
 
+	// consent is assumed for this run only; nothing was typed, so nothing is saved
+	if assumeYes {
+		fmt.Println()
+		fmt.Print(warningStyle.Render("  ⚠ first-run consent assumed"))
+		fmt.Print(" ")
+		fmt.Println(dimStyle.Render("• --yes"))
+		return true, nil
+	}
+
+	if !canPrompt() {
+		return false, ErrNoTerminal
+	}
+
 	// Bubble Tea prompt
 	prompt := lipgloss.JoinVertical(lipgloss.Left,
 		warningStyle.Render(" ⚠ This is your first time using --run to automatically execute a command."),
@@ -211,14 +387,239 @@ func ensureRunConsent() (bool, error) {
 	return true, nil
 }
 
-func Execute(command string, cfg *config.Config, usedSudoFlag bool) error {
+// verifyDisplayed guards against the command being altered somewhere between
+// display and execution (re-parsing, caching, prefixing).
+func verifyDisplayed(final string, opts Options) error {
+	if opts.Displayed == "" {
+		return nil
+	}
+
+	expected := strings.TrimSpace(opts.Displayed)
+	if opts.Sudo {
+		expected = "sudo " + expected
+	}
+
+	if final != expected {
+		return fmt.Errorf("refusing to run: command differs from what was displayed\n  shown: %s\n  final: %s", expected, final)
+	}
+	return nil
+}
+
+// autoApproved reports whether every risk reason matches one of the user's
+// auto_approve_reasons substrings. A single unmatched reason means the normal
+// prompt is shown, and critical commands, which include every blacklisted
+// binary, are never auto-approved.
+func autoApproved(assessment RiskAssessment, cfg *config.Config) bool {
+	if cfg == nil || len(cfg.AutoApproveReasons) == 0 || len(assessment.Reasons) == 0 {
+		return false
+	}
+	if assessment.Level >= RiskCritical {
+		return false
+	}
+
+	for _, reason := range assessment.Reasons {
+		approved := false
+		for _, allowed := range cfg.AutoApproveReasons {
+			allowed = strings.ToLower(strings.TrimSpace(allowed))
+			if allowed != "" && strings.Contains(strings.ToLower(reason), allowed) {
+				approved = true
+				break
+			}
+		}
+		if !approved {
+			return false
+		}
+	}
+	return true
+}
+
+// autoRiskBlocked reports whether assessment reaches the user's
+// max_auto_risk_level, returning that limit. An empty setting never blocks.
+func autoRiskBlocked(assessment RiskAssessment, cfg *config.Config) (RiskLevel, bool) {
+	if cfg == nil || strings.TrimSpace(cfg.MaxAutoRiskLevel) == "" {
+		return RiskNone, false
+	}
+	limit := parseRiskLevel(cfg.MaxAutoRiskLevel)
+	return limit, assessment.Level >= limit
+}
+
+// workDir returns the directory the command runs in: opts.Dir, or else the
+// current one.
+func workDir(opts Options) (string, error) {
+	if opts.Dir != "" {
+		return filepath.Abs(opts.Dir)
+	}
+	return os.Getwd()
+}
+
+// trustedDir returns the entry of trusted_dirs that contains the working
+// directory, if any. Both sides are made absolute and have symlinks resolved,
+// so a symlinked path cannot be used to step into or out of a trusted tree.
+func trustedDir(cfg *config.Config, opts Options) (string, bool) {
+	if cfg == nil || len(cfg.TrustedDirs) == 0 {
+		return "", false
+	}
+
+	cwd, err := workDir(opts)
+	if err != nil {
+		return "", false
+	}
+	cwd, err = resolveDir(cwd)
+	if err != nil {
+		return "", false
+	}
+
+	for _, dir := range cfg.TrustedDirs {
+		if strings.TrimSpace(dir) == "" {
+			continue
+		}
+		resolved, err := resolveDir(dir)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(resolved, cwd)
+		if err != nil {
+			continue
+		}
+		if rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))) {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+func resolveDir(dir string) (string, error) {
+	if dir == "~" || strings.HasPrefix(dir, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, dir[1:])
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// printRiskBox renders the full risk warning: every reason numbered in a box,
+// plus the glob preview, sandbox and, for High/Critical, the exact command.
+func printRiskBox(trimmed string, assessment RiskAssessment, cfg *config.Config, sandbox []string, opts Options) {
+	fmt.Println()
+	fmt.Print(warningStyle.Render(" ❯ Command requires caution"))
+	fmt.Println()
+
+	fmt.Println(dimStyle.Render("  ┌─────────────────────────────────────────"))
+
+	for i, r := range assessment.Reasons {
+		fmt.Printf("%s %d) %s\n", dimStyle.Render("  │"), i+1, dimStyle.Render(r))
+	}
+
+	// Show what the globs actually hit, so the warning is about concrete files.
+	// Opt-in because it reads the filesystem before the user has agreed to anything.
+	if cfg != nil && cfg.PreviewGlobMatches {
+		printGlobPreview(expandGlobTargets(trimmed, opts.Dir))
+	}
+
+	if sandbox != nil {
+		fmt.Println(dimStyle.Render("  │"))
+		fmt.Printf("%s %s %s\n", dimStyle.Render("  │"), dimStyle.Render("sandboxed:"), whiteStyle.Render(formatInvocation(shellArgv(sandbox, opts.Shell, trimmed))))
+	}
+
+	// For High/Critical risk, re-display the exact final string and make the
+	// user confirm it is what they expect to run.
+	if assessment.Level >= RiskHigh {
+		fmt.Println(dimStyle.Render("  │"))
+		fmt.Print(dimStyle.Render("  │ "))
+		fmt.Print(cyanStyle.Render("❯"))
+		fmt.Print(" ")
+		fmt.Println(commandStyle.Render(trimmed))
+	}
+	fmt.Println(dimStyle.Render("  └─────────────────────────────────────────"))
+	fmt.Println()
+}
+
+// printRiskLine renders the compact risk_display form on one line, e.g.
+// "⚠ High [rm -rf detected, network] — Proceed? [y/N]". The question is left
+// off when the command is auto-approved.
+func printRiskLine(assessment RiskAssessment, sandbox []string, ask bool) {
+	fmt.Println()
+	fmt.Print(warningStyle.Render("  ⚠ " + assessment.Level.String()))
+	fmt.Print(" ")
+	fmt.Print(dimStyle.Render("[" + strings.Join(assessment.Reasons, ", ") + "]"))
+	if sandbox != nil {
+		fmt.Print(dimStyle.Render(" • sandboxed"))
+	}
+	if ask {
+		question := "Proceed? [y/N]"
+		if assessment.Level >= RiskHigh {
+			question = "Type 'yes' to proceed:"
+		}
+		fmt.Print(" ")
+		fmt.Print(cyanStyle.Render("— " + question))
+	}
+	fmt.Println()
+}
+
+// dryRun shows the risk assessment and the final command, including any sudo
+// prefix and sandbox, then stops. Nothing is run and the consent file is untouched.
+func dryRun(trimmed string, assessment RiskAssessment, cfg *config.Config, opts Options, needsSudo bool) error {
+	sandbox, err := sandboxArgs(cfg)
+	if err != nil {
+		fmt.Println()
+		fmt.Print(warningStyle.Render("  ⚠ " + err.Error()))
+		fmt.Print(" ")
+		fmt.Println(dimStyle.Render("• would run without sandbox"))
+	}
+
+	if len(assessment.Reasons) > 0 {
+		if cfg != nil && cfg.RiskDisplay == "compact" {
+			printRiskLine(assessment, sandbox, false)
+		} else {
+			printRiskBox(trimmed, assessment, cfg, sandbox, opts)
+		}
+	}
+
+	printCommand(trimmed, needsSudo, sandbox, opts.Shell)
+
+	if !opts.Quiet {
+		fmt.Println()
+		fmt.Print(warningStyle.Render("  ◇ DRY RUN"))
+		fmt.Print(" ")
+		fmt.Println(dimStyle.Render("• not executed"))
+		fmt.Println()
+	}
+	return nil
+}
+
+func Execute(command string, cfg *config.Config, opts Options) error {
 	trimmed := strings.TrimSpace(command)
-	assessment := AssessCommandRisk(trimmed, usedSudoFlag)
+	if err := verifyDisplayed(trimmed, opts); err != nil {
+		return err
+	}
+
+	if opts.Shell != "" {
+		if _, err := exec.LookPath(opts.Shell); err != nil {
+			return fmt.Errorf("shell %s not found", opts.Shell)
+		}
+	}
+	if opts.Dir != "" {
+		if info, err := os.Stat(opts.Dir); err != nil || !info.IsDir() {
+			return fmt.Errorf("directory %s not found", opts.Dir)
+		}
+	}
+
+	assessment := AssessCommandRisk(trimmed, opts.Sudo, cfg)
 
 	needsSudo := strings.HasPrefix(trimmed, "sudo ")
 	hasRiskAssessmentIssues := len(assessment.Reasons) > 0
 
-	ok, err := ensureRunConsent()
+	if opts.DryRun {
+		return dryRun(trimmed, assessment, cfg, opts, needsSudo)
+	}
+
+	ok, err := ensureRunConsent(opts.AssumeYes)
 	if err != nil {
 		return err
 	}
@@ -226,39 +627,69 @@ func Execute(command string, cfg *config.Config, usedSudoFlag bool) error {
 		return nil
 	}
 
-	// Case 1: Risks detected
-	if hasRiskAssessmentIssues {
-		fmt.Println()
-		fmt.Print(warningStyle.Render(" ❯ Command requires caution"))
+	sandbox, err := sandboxArgs(cfg)
+	if err != nil {
 		fmt.Println()
+		fmt.Print(warningStyle.Render("  ⚠ " + err.Error()))
+		fmt.Print(" ")
+		fmt.Println(dimStyle.Render("• running without sandbox"))
+	}
 
-		fmt.Println(dimStyle.Render("  ┌─────────────────────────────────────────"))
-
-		for i, r := range assessment.Reasons {
-			fmt.Printf("%s %d) %s\n", dimStyle.Render("  │"), i+1, dimStyle.Render(r))
+	// Case 1: Risks detected
+	if hasRiskAssessmentIssues {
+		highRisk := assessment.Level >= RiskHigh
+		compact := cfg != nil && cfg.RiskDisplay == "compact"
+
+		approved := ""
+		if dir, ok := trustedDir(cfg, opts); ok && assessment.Level < RiskCritical {
+			approved = "running inside trusted dir " + dir
+		} else if autoApproved(assessment, cfg) {
+			approved = "every reason is listed in auto_approve_reasons"
+		} else if opts.AssumeYes {
+			approved = "confirmed with --yes"
 		}
 
-		//fmt.Println(dimStyle.Render("  │"))
-		//fmt.Print(dimStyle.Render("  │ "))
-		//fmt.Print(cyanStyle.Render("❯"))
-		//fmt.Print(" ")
-		//fmt.Println(commandStyle.Render(trimmed))
-		fmt.Println(dimStyle.Render("  └─────────────────────────────────────────"))
-		fmt.Println()
-		fmt.Println(cyanStyle.Render("Proceed? [y/N]"))
+		if compact {
+			printRiskLine(assessment, sandbox, approved == "" && canPrompt())
+		} else {
+			printRiskBox(trimmed, assessment, cfg, sandbox, opts)
+		}
 
-		p := tea.NewProgram(initialModel("", "", false))
-		m, err := p.Run()
-		if err != nil {
-			return fmt.Errorf("failed to show confirmation prompt: %w", err)
+		if limit, blocked := autoRiskBlocked(assessment, cfg); blocked && (approved != "" || !canPrompt()) {
+			return fmt.Errorf("%w: %s risk reaches max_auto_risk_level %s; run it from a terminal without --yes to confirm it yourself", ErrRiskBlocked, assessment.Level, limit)
 		}
-		result := m.(confirmModel)
-		if result.cancelled || !result.confirmed {
-			fmt.Print(cancelStyle.Render("  ✗ CANCELLED"))
+		if approved == "" && !canPrompt() {
+			return ErrNoTerminal
+		}
+
+		if approved != "" {
+			fmt.Print(successStyle.Render("  ✓ AUTO-APPROVED"))
 			fmt.Print(" ")
-			fmt.Println(dimStyle.Render("• user aborted"))
-			fmt.Println()
-			return nil
+			fmt.Println(dimStyle.Render("• " + approved))
+		} else {
+			model := initialModel("", "", false)
+			switch {
+			case highRisk && compact:
+				model = initialModel("", "yes", false)
+			case highRisk:
+				model = initialModel(cyanStyle.Render("Is the command above exactly what you expect to run? Type 'yes' to proceed:"), "yes", false)
+			case !compact:
+				fmt.Println(cyanStyle.Render("Proceed? [y/N]"))
+			}
+
+			p := tea.NewProgram(model)
+			m, err := p.Run()
+			if err != nil {
+				return fmt.Errorf("failed to show confirmation prompt: %w", err)
+			}
+			result := m.(confirmModel)
+			if result.cancelled || !result.confirmed {
+				fmt.Print(cancelStyle.Render("  ✗ CANCELLED"))
+				fmt.Print(" ")
+				fmt.Println(dimStyle.Render("• user aborted"))
+				fmt.Println()
+				return nil
+			}
 		}
 
 		if needsSudo {
@@ -267,10 +698,13 @@ func Execute(command string, cfg *config.Config, usedSudoFlag bool) error {
 			}
 		}
 
-		printCommand(trimmed, needsSudo)
+		printCommand(trimmed, needsSudo, sandbox, opts.Shell)
 
 	} else if needsSudo {
-		if usedSudoFlag {
+		if opts.Sudo && !opts.AssumeYes {
+			if !canPrompt() {
+				return ErrNoTerminal
+			}
 			p := tea.NewProgram(initialModel("", "", true))
 			m, err := p.Run()
 			if err != nil {
@@ -290,11 +724,16 @@ func Execute(command string, cfg *config.Config, usedSudoFlag bool) error {
 			return err
 		}
 
-		printCommand(trimmed, true)
+		printCommand(trimmed, true, sandbox, opts.Shell)
 
 	} else {
-		printCommand(trimmed, false)
+		printCommand(trimmed, false, sandbox, opts.Shell)
+	}
+
+	// refuse to run what cannot be recorded; the user can opt out explicitly
+	if err := writeAudit(trimmed, assessment, cfg, opts); err != nil {
+		return fmt.Errorf("%w (use --no-audit to run without the audit log)", err)
 	}
 
-	return runCommand(trimmed)
+	return runCommand(trimmed, opts, sandbox)
 }
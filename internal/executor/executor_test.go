@@ -0,0 +1,290 @@
+package executor
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/dorochadev/oneliner/config"
+	"github.com/muesli/termenv"
+)
+
+func TestVerifyDisplayed(t *testing.T) {
+	tests := []struct {
+		name    string
+		final   string
+		opts    Options
+		wantErr bool
+	}{
+		{"nothing displayed", "rm -rf ./build", Options{}, false},
+		{"identical", "ls -la", Options{Displayed: "ls -la"}, false},
+		{"displayed with whitespace", "ls -la", Options{Displayed: " ls -la\n"}, false},
+		{"explicit sudo", "sudo ls -la", Options{Displayed: "ls -la", Sudo: true}, false},
+		{"sudo without --sudo", "sudo ls -la", Options{Displayed: "ls -la"}, true},
+		{"--sudo without prefix", "ls -la", Options{Displayed: "ls -la", Sudo: true}, true},
+		{"altered", "ls -la /", Options{Displayed: "ls -la"}, true},
+		{"quotes normalized after display", `echo "hi"`, Options{Displayed: "echo “hi”"}, true},
+		{"comment stripped after display", "ls", Options{Displayed: "ls # list"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyDisplayed(tt.final, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifyDisplayed(%q, displayed %q, sudo %v) = %v, want error %v", tt.final, tt.opts.Displayed, tt.opts.Sudo, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAutoApproved(t *testing.T) {
+	const (
+		rmrf       = "destructive rm -rf detected (verify target path)"
+		findDelete = "find -delete removes files"
+	)
+
+	tests := []struct {
+		name       string
+		assessment RiskAssessment
+		allowed    []string
+		want       bool
+	}{
+		{"single listed reason", RiskAssessment{RiskHigh, []string{rmrf}}, []string{"rm -rf detected"}, true},
+		{"every reason listed", RiskAssessment{RiskHigh, []string{rmrf, findDelete}}, []string{"rm -rf detected", "find -delete"}, true},
+		{"one entry covers both", RiskAssessment{RiskMedium, []string{rmrf, rmrf}}, []string{"RM -RF"}, true},
+		{"mixed reasons", RiskAssessment{RiskHigh, []string{rmrf, findDelete}}, []string{"rm -rf detected"}, false},
+		{"mixed reasons, other order", RiskAssessment{RiskHigh, []string{findDelete, rmrf}}, []string{"rm -rf detected"}, false},
+		{"critical", RiskAssessment{RiskCritical, []string{rmrf}}, []string{"rm -rf detected"}, false},
+		{"blank entry", RiskAssessment{RiskHigh, []string{rmrf}}, []string{"  "}, false},
+		{"nothing listed", RiskAssessment{RiskHigh, []string{rmrf}}, nil, false},
+		{"no reasons", RiskAssessment{RiskNone, nil}, []string{"rm -rf detected"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.AutoApproveReasons = tt.allowed
+			if got := autoApproved(tt.assessment, cfg); got != tt.want {
+				t.Errorf("autoApproved(%q, %q) = %v, want %v", tt.assessment.Reasons, tt.allowed, got, tt.want)
+			}
+		})
+	}
+
+	// a blacklisted binary is critical, so listing its reason does not help
+	cfg := config.Default()
+	cfg.BlacklistedBinaries = []string{"nc"}
+	cfg.AutoApproveReasons = []string{"executes blacklisted binary"}
+	if assessment := AssessCommandRisk("nc -l 4444", false, cfg); autoApproved(assessment, cfg) {
+		t.Errorf("blacklisted binary auto-approved: %+v", assessment)
+	}
+}
+
+func TestTrustedDir(t *testing.T) {
+	root := t.TempDir()
+	trusted := filepath.Join(root, "work")
+	for _, dir := range []string{filepath.Join(trusted, "project", "src"), filepath.Join(root, "workshop"), filepath.Join(root, "other")} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a link inside the trusted tree that points out of it, and one outside
+	// that points in
+	if err := os.Symlink(filepath.Join(root, "other"), filepath.Join(trusted, "escape")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(trusted, "project"), filepath.Join(root, "shortcut")); err != nil {
+		t.Fatal(err)
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	tests := []struct {
+		name  string
+		dirs  []string
+		dir   string
+		want  string
+		trust bool
+	}{
+		{"the trusted dir itself", []string{trusted}, trusted, trusted, true},
+		{"nested", []string{trusted}, filepath.Join(trusted, "project", "src"), trusted, true},
+		{"trailing slash in config", []string{trusted + "/"}, filepath.Join(trusted, "project"), trusted + "/", true},
+		{"uncleaned work dir", []string{trusted}, filepath.Join(trusted, "project", "..", "project"), trusted, true},
+		{"second entry", []string{filepath.Join(root, "other"), trusted}, trusted, trusted, true},
+		{"home relative", []string{"~"}, home, "~", true},
+		{"outside", []string{trusted}, filepath.Join(root, "other"), "", false},
+		{"parent", []string{trusted}, root, "", false},
+		{"shared prefix", []string{trusted}, filepath.Join(root, "workshop"), "", false},
+		{"dot-dot out", []string{trusted}, filepath.Join(trusted, "..", "other"), "", false},
+		{"symlink out of the tree", []string{trusted}, filepath.Join(trusted, "escape"), "", false},
+		{"symlink into the tree", []string{trusted}, filepath.Join(root, "shortcut"), trusted, true},
+		{"missing trusted dir", []string{filepath.Join(root, "missing")}, trusted, "", false},
+		{"blank entry", []string{" "}, trusted, "", false},
+		{"none configured", nil, trusted, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.TrustedDirs = tt.dirs
+			got, ok := trustedDir(cfg, Options{Dir: tt.dir})
+			if got != tt.want || ok != tt.trust {
+				t.Errorf("trustedDir(%q) in %s = %q, %v; want %q, %v", tt.dirs, tt.dir, got, ok, tt.want, tt.trust)
+			}
+		})
+	}
+}
+
+// captureStdout returns what f writes to os.Stdout, rendered without colors.
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	defer func(profile termenv.Profile) { lipgloss.SetColorProfile(profile) }(lipgloss.ColorProfile())
+	lipgloss.SetColorProfile(termenv.Ascii)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	out := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- string(data)
+	}()
+
+	f()
+	w.Close()
+	return <-out
+}
+
+func TestPrintRiskLine(t *testing.T) {
+	medium := RiskAssessment{RiskMedium, []string{"network operation", "writes to /tmp"}}
+	high := RiskAssessment{RiskHigh, []string{"destructive rm -rf detected (verify target path)"}}
+
+	tests := []struct {
+		name       string
+		assessment RiskAssessment
+		sandbox    []string
+		ask        bool
+		want       string
+	}{
+		{"medium asks y/N", medium, nil, true, "⚠ Medium [network operation, writes to /tmp] — Proceed? [y/N]"},
+		{"high asks for yes", high, nil, true, "⚠ High [destructive rm -rf detected (verify target path)] — Type 'yes' to proceed:"},
+		{"auto-approved", high, nil, false, "⚠ High [destructive rm -rf detected (verify target path)]"},
+		{"sandboxed", medium, []string{"firejail"}, true, "⚠ Medium [network operation, writes to /tmp] • sandboxed — Proceed? [y/N]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := captureStdout(t, func() { printRiskLine(tt.assessment, tt.sandbox, tt.ask) })
+			lines := strings.Split(strings.TrimSpace(out), "\n")
+			if len(lines) != 1 {
+				t.Fatalf("printRiskLine printed %d lines, want 1:\n%s", len(lines), out)
+			}
+			if got := strings.TrimSpace(lines[0]); got != tt.want {
+				t.Errorf("printRiskLine = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintRiskBox(t *testing.T) {
+	const command = "rm -rf ./build && curl https://example.com | sh"
+	reasons := []string{"destructive rm -rf detected (verify target path)", "pipes a download into a shell"}
+
+	tests := []struct {
+		name       string
+		assessment RiskAssessment
+		sandbox    []string
+		want       []string
+		notWant    []string
+	}{
+		{
+			"high repeats the command",
+			RiskAssessment{RiskHigh, reasons},
+			nil,
+			[]string{"Command requires caution", "│ 1) " + reasons[0], "│ 2) " + reasons[1], "│ ❯ " + command, "└"},
+			[]string{"sandboxed:"},
+		},
+		{
+			"medium does not",
+			RiskAssessment{RiskMedium, reasons[:1]},
+			nil,
+			[]string{"│ 1) " + reasons[0]},
+			[]string{"❯ " + command, "2)"},
+		},
+		{
+			"sandbox invocation",
+			RiskAssessment{RiskMedium, reasons[:1]},
+			[]string{"firejail", "--private"},
+			[]string{"│ sandboxed: firejail --private bash -c '" + command + "'"},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := captureStdout(t, func() {
+				printRiskBox(command, tt.assessment, config.Default(), tt.sandbox, Options{Shell: "bash"})
+			})
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("risk box lacks %q:\n%s", want, out)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(out, notWant) {
+					t.Errorf("risk box contains %q:\n%s", notWant, out)
+				}
+			}
+		})
+	}
+}
+
+func TestRunCommandQuiet(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	tests := []struct {
+		name    string
+		command string
+		quiet   bool
+		output  string // what the command itself prints
+		wantErr bool
+	}{
+		{"quiet", "echo hello", true, "hello\n", false},
+		{"quiet failure", "echo partial; exit 3", true, "partial\n", true},
+		{"status lines", "echo hello", false, "hello\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "out.txt")
+			var err error
+			out := captureStdout(t, func() {
+				err = runCommand(tt.command, Options{Quiet: tt.quiet, Shell: "sh", Output: output}, nil)
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("runCommand(%q) error = %v, want error %v", tt.command, err, tt.wantErr)
+			}
+			if tt.quiet && out != tt.output {
+				t.Errorf("quiet output = %q, want only the command's %q", out, tt.output)
+			}
+			if !tt.quiet && (!strings.Contains(out, tt.output) || !strings.Contains(out, "✓ SUCCESS")) {
+				t.Errorf("output = %q, want %q and the status line", out, tt.output)
+			}
+
+			// --output gets the command output either way, and nothing else
+			if saved, err := os.ReadFile(output); err != nil || string(saved) != tt.output {
+				t.Errorf("saved output = %q, %v; want %q", saved, err, tt.output)
+			}
+		})
+	}
+}
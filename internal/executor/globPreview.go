@@ -0,0 +1,106 @@
+package executor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// globPreviewLimit caps how many matched paths are listed per pattern.
+const globPreviewLimit = 5
+
+// globPreviewCommands are the file operations whose glob arguments get expanded
+// in the confirmation preview.
+var globPreviewCommands = map[string]bool{
+	"rm": true, "chmod": true, "chown": true, "chgrp": true, "shred": true,
+}
+
+type globMatch struct {
+	Pattern string
+	Paths   []string
+}
+
+// expandGlobTargets resolves unquoted glob arguments of rm/chmod-style commands
+// against the filesystem, the same way the shell is about to. Relative patterns
+// are resolved in dir, or the current directory when dir is empty. Quoted patterns
+// and anything involving variables or substitutions are skipped rather than guessed at.
+func expandGlobTargets(cmd, dir string) []globMatch {
+	var matches []globMatch
+
+	for _, segment := range splitCommandSegments(cmd) {
+		fields := strings.Fields(segment)
+		if len(fields) > 0 && fields[0] == "sudo" {
+			fields = fields[1:]
+		}
+		if len(fields) == 0 || !globPreviewCommands[filepath.Base(fields[0])] {
+			continue
+		}
+
+		for _, arg := range fields[1:] {
+			if strings.HasPrefix(arg, "-") || !strings.ContainsAny(arg, "*?[") {
+				continue
+			}
+			if strings.ContainsAny(arg, "'\"`$\\") {
+				continue
+			}
+
+			pattern := arg
+			if strings.HasPrefix(pattern, "~/") {
+				if home, err := os.UserHomeDir(); err == nil {
+					pattern = filepath.Join(home, pattern[2:])
+				}
+			}
+
+			relative := dir != "" && !filepath.IsAbs(pattern)
+			if relative {
+				pattern = filepath.Join(dir, pattern)
+			}
+
+			paths, err := filepath.Glob(pattern)
+			if err != nil {
+				continue
+			}
+			if relative {
+				// list them the way they are written in the command
+				for i, p := range paths {
+					if rel, err := filepath.Rel(dir, p); err == nil {
+						paths[i] = rel
+					}
+				}
+			}
+			matches = append(matches, globMatch{Pattern: arg, Paths: paths})
+		}
+	}
+
+	return matches
+}
+
+// printGlobPreview lists what each glob in the command currently matches,
+// inside the risk box drawn by Execute.
+func printGlobPreview(matches []globMatch) {
+	for _, m := range matches {
+		fmt.Println(dimStyle.Render("  │"))
+
+		if len(m.Paths) == 0 {
+			fmt.Printf("%s %s %s\n", dimStyle.Render("  │"), whiteStyle.Render(m.Pattern), dimStyle.Render("matches nothing"))
+			continue
+		}
+
+		noun := "paths"
+		if len(m.Paths) == 1 {
+			noun = "path"
+		}
+		fmt.Printf("%s %s %s\n",
+			dimStyle.Render("  │"),
+			whiteStyle.Render(m.Pattern),
+			warningStyle.Render(fmt.Sprintf("matches %d %s:", len(m.Paths), noun)))
+
+		for _, p := range m.Paths[:min(globPreviewLimit, len(m.Paths))] {
+			fmt.Printf("%s   %s\n", dimStyle.Render("  │"), dimStyle.Render(p))
+		}
+		if extra := len(m.Paths) - globPreviewLimit; extra > 0 {
+			fmt.Printf("%s   %s\n", dimStyle.Render("  │"), dimStyle.Render(fmt.Sprintf("… and %d more", extra)))
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestExpandGlobTargets(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.log", "b.log", "c.txt", "a;b.tmp"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		command string
+		want    []globMatch
+	}{
+		{"relative", "rm *.log", []globMatch{{"*.log", []string{"a.log", "b.log"}}}},
+		{"sudo and flags", "sudo rm -f *.txt", []globMatch{{"*.txt", []string{"c.txt"}}}},
+		{"no match", "chmod 600 *.key", []globMatch{{"*.key", nil}}},
+		{"absolute", "rm " + filepath.Join(dir, "?.txt"), []globMatch{{filepath.Join(dir, "?.txt"), []string{filepath.Join(dir, "c.txt")}}}},
+		{"after separator", "cd . && rm *.log", []globMatch{{"*.log", []string{"a.log", "b.log"}}}},
+		{"separator inside quotes", `rm "a;b"*`, nil},
+		{"quoted separator before the glob", `rm "old;" *.log`, []globMatch{{"*.log", []string{"a.log", "b.log"}}}},
+		{"separator inside single quotes", `echo 'x; rm *.log'`, nil},
+		{"quoted pattern", `rm "*.log"`, nil},
+		{"variable", "rm $DIR/*.log", nil},
+		{"not a file operation", "ls *.log", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := expandGlobTargets(tt.command, dir)
+			if !slices.EqualFunc(got, tt.want, func(a, b globMatch) bool {
+				return a.Pattern == b.Pattern && slices.Equal(a.Paths, b.Paths)
+			}) {
+				t.Errorf("expandGlobTargets(%q) = %q, want %q", tt.command, got, tt.want)
+			}
+		})
+	}
+}
@@ -8,72 +8,118 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// Action is what the user picked in the interactive command palette.
+type Action int
+
+const (
+	ActionCancel Action = iota
+	ActionRun
+	ActionRegenerate
+	ActionCopy
+	ActionExplain
+)
+
+// InteractionModel is a small command palette around a generated command:
+// enter runs it, e edits it in place, r regenerates, c copies and x explains.
 type InteractionModel struct {
-	textInput       textinput.Model
-	Confirmed       bool
-	Cancelled       bool
-	showSudoWarning bool
-	prompt          string
-	expectedInput   string
+	textInput textinput.Model
+	editing   bool
+	// Command is the command as shown to the user, including any edits.
+	Command string
+	// Edited reports whether the user changed the command.
+	Edited bool
+	Action Action
 }
 
-func InterationModel(prompt, expectedInput string, showSudoWarning bool) InteractionModel {
+func NewInteractionModel(command string) InteractionModel {
 	ti := textinput.New()
-	ti.Placeholder = expectedInput
-	ti.Focus()
-	ti.CharLimit = len(expectedInput) + 5
-	ti.Width = 50
+	ti.CharLimit = 0
+	ti.Width = 80
 
 	return InteractionModel{
-		textInput:       ti,
-		showSudoWarning: showSudoWarning,
-		prompt:          prompt,
-		expectedInput:   expectedInput,
+		textInput: ti,
+		Command:   command,
 	}
 }
 
 func (m InteractionModel) Init() tea.Cmd {
-	return textinput.Blink
+	return nil
 }
 
 func (m InteractionModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
+	if m.editing {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			switch msg.String() {
+			case "ctrl+c":
+				m.Action = ActionCancel
+				return m, tea.Quit
+			case "esc":
+				// discard the edit and go back to the palette
+				m.editing = false
+				m.textInput.Blur()
+				return m, nil
+			case "enter":
+				edited := strings.TrimSpace(m.textInput.Value())
+				if edited != "" && edited != m.Command {
+					m.Command = edited
+					m.Edited = true
+				}
+				m.editing = false
+				m.textInput.Blur()
+				return m, nil
+			}
+		}
+
+		m.textInput, cmd = m.textInput.Update(msg)
+		return m, cmd
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
-		case "ctrl+c", "esc":
-			m.Cancelled = true
+		case "ctrl+c", "esc", "q", "n":
+			m.Action = ActionCancel
 			return m, tea.Quit
-		case "enter":
-			input := strings.TrimSpace(strings.ToLower(m.textInput.Value()))
-			if m.expectedInput != "" && input == strings.ToLower(m.expectedInput) {
-				m.Confirmed = true
-			} else if m.expectedInput == "" && (input == "y" || input == "Y") {
-				m.Confirmed = true
-			} else {
-				m.Cancelled = true
-			}
+		case "enter", "y":
+			m.Action = ActionRun
+			return m, tea.Quit
+		case "e":
+			m.editing = true
+			m.textInput.SetValue(m.Command)
+			m.textInput.CursorEnd()
+			m.textInput.Focus()
+			return m, textinput.Blink
+		case "r":
+			m.Action = ActionRegenerate
+			return m, tea.Quit
+		case "c":
+			m.Action = ActionCopy
+			return m, tea.Quit
+		case "x":
+			m.Action = ActionExplain
 			return m, tea.Quit
 		}
 	}
 
-	m.textInput, cmd = m.textInput.Update(msg)
-	return m, cmd
+	return m, nil
 }
 
 func (m InteractionModel) View() string {
-	if m.showSudoWarning {
+	if m.editing {
 		return fmt.Sprintf(
-			"\n%s\n%s",
-			cyanStyle.Render("Proceed with sudo? [y/N]"),
+			"\n%s\n%s\n\n%s\n",
+			cyanStyle.Render("Edit command:"),
 			m.textInput.View(),
+			dimStyle.Render("  enter save • esc discard"),
 		)
 	}
 
-	if m.prompt != "" {
-		return fmt.Sprintf("%s\n\n%s", m.prompt, m.textInput.View())
-	}
-
-	return fmt.Sprintf("%s\n\n", m.textInput.View())
+	return fmt.Sprintf(
+		"\n%s %s\n\n%s\n",
+		cyanStyle.Render("❯"),
+		whiteStyle.Render(m.Command),
+		dimStyle.Render("  enter run • e edit • r regenerate • c copy • x explain • esc cancel"),
+	)
 }
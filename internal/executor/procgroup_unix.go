@@ -0,0 +1,71 @@
+//go:build !windows
+
+package executor
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+	"golang.org/x/term"
+)
+
+// setProcessGroup makes cmd the leader of a new process group so a signal can
+// reach everything the command spawns. When stdin is a terminal the group is
+// also moved to the foreground, so the command can still read from it and
+// Ctrl+C goes straight to it. The returned func gives the terminal back to
+// oneliner and must be called once the command has exited.
+func setProcessGroup(cmd *exec.Cmd) (restore func()) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		return func() {}
+	}
+
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Foreground: true, Ctty: fd}
+	return func() {
+		// we are a background group at this point; without ignoring SIGTTOU
+		// taking the terminal back would stop oneliner
+		signal.Ignore(syscall.SIGTTOU)
+		defer signal.Reset(syscall.SIGTTOU)
+		_ = unix.IoctlSetPointerInt(fd, unix.TIOCSPGRP, unix.Getpgrp())
+	}
+}
+
+// signalProcessGroup forwards sig to every process in cmd's group.
+func signalProcessGroup(cmd *exec.Cmd, sig os.Signal) {
+	if cmd.Process == nil {
+		return
+	}
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		s = syscall.SIGINT
+	}
+	_ = syscall.Kill(-cmd.Process.Pid, s)
+}
+
+// killProcessGroup sends SIGKILL to every process in cmd's group.
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// killedBySignal reports whether the command ended because of SIGINT or
+// SIGTERM, including a Ctrl+C delivered by the terminal directly.
+func killedBySignal(state *os.ProcessState) bool {
+	if state == nil {
+		return false
+	}
+	ws, ok := state.Sys().(syscall.WaitStatus)
+	if !ok {
+		return false
+	}
+	if ws.Signaled() {
+		return ws.Signal() == syscall.SIGINT || ws.Signal() == syscall.SIGTERM
+	}
+	// shells exit with 128+SIGINT when their foreground job was interrupted
+	return ws.ExitStatus() == 128+int(syscall.SIGINT)
+}
@@ -0,0 +1,145 @@
+//go:build !windows
+
+package executor
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// startInGroup runs script with runInGroup and waits until it has written
+// the pid of its background child, so the signal handler is installed.
+func startInGroup(t *testing.T, script string) (pid int, result <-chan error) {
+	t.Helper()
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	cmd := exec.Command("sh", "-c", script, "sh", pidFile)
+
+	done := make(chan error, 1)
+	go func() { done <- runInGroup(cmd) }()
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		data, err := os.ReadFile(pidFile)
+		if err != nil || !strings.HasSuffix(string(data), "\n") {
+			continue
+		}
+		if pid, err = strconv.Atoi(strings.TrimSpace(string(data))); err != nil {
+			t.Fatal(err)
+		}
+		return pid, done
+	}
+	t.Fatal("command did not start")
+	return 0, nil
+}
+
+// waitGone waits for pid to disappear, reporting whether it did. An orphan
+// lingers as a zombie until init reaps it, so this can take a moment.
+func waitGone(pid int) bool {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if err := syscall.Kill(pid, 0); errors.Is(err, syscall.ESRCH) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRunInGroupForwardsSignals(t *testing.T) {
+	// the background sleep is in the command's group but is not a child of
+	// oneliner, so only a signal to the whole group reaches it
+	pid, done := startInGroup(t, `sleep 30 & echo $! > "$1"; wait`)
+	start := time.Now()
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrInterrupted) {
+			t.Errorf("runInGroup = %v, want ErrInterrupted", err)
+		}
+	case <-time.After(2 * killGracePeriod):
+		t.Fatal("runInGroup did not return after SIGTERM")
+	}
+	if elapsed := time.Since(start); elapsed >= killGracePeriod {
+		t.Errorf("command took %v to stop, so it was killed rather than signalled", elapsed)
+	}
+	if !waitGone(pid) {
+		syscall.Kill(pid, syscall.SIGKILL)
+		t.Errorf("background process %d survived the forwarded signal", pid)
+	}
+}
+
+func TestRunInGroupKillsAfterGracePeriod(t *testing.T) {
+	if testing.Short() {
+		t.Skip("waits for the kill grace period")
+	}
+
+	pid, done := startInGroup(t, `trap '' TERM; sleep 30 & echo $! > "$1"; wait`)
+	start := time.Now()
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrInterrupted) {
+			t.Errorf("runInGroup = %v, want ErrInterrupted", err)
+		}
+	case <-time.After(3 * killGracePeriod):
+		t.Fatal("runInGroup did not kill a group that ignores SIGTERM")
+	}
+	if elapsed := time.Since(start); elapsed < killGracePeriod {
+		t.Errorf("group ignoring SIGTERM stopped after %v, before the grace period", elapsed)
+	}
+	if !waitGone(pid) {
+		syscall.Kill(pid, syscall.SIGKILL)
+		t.Errorf("background process %d survived the kill", pid)
+	}
+}
+
+func TestRunCommandQuietInterrupted(t *testing.T) {
+	tests := []struct {
+		name  string
+		quiet bool
+		want  string // "" means nothing but the command output
+	}{
+		{"quiet", true, ""},
+		{"status lines", false, "✗ INTERRUPTED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pidFile := filepath.Join(t.TempDir(), "pid")
+			command := `echo started; echo $$ > '` + pidFile + `'; sleep 30`
+
+			var err error
+			out := captureStdout(t, func() {
+				go func() {
+					for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+						if data, err := os.ReadFile(pidFile); err == nil && strings.HasSuffix(string(data), "\n") {
+							syscall.Kill(os.Getpid(), syscall.SIGTERM)
+							return
+						}
+					}
+				}()
+				err = runCommand(command, Options{Quiet: tt.quiet, Shell: "sh"}, nil)
+			})
+
+			if !errors.Is(err, ErrInterrupted) {
+				t.Errorf("runCommand = %v, want ErrInterrupted", err)
+			}
+			if tt.want == "" && out != "started\n" {
+				t.Errorf("quiet interrupted output = %q, want only the command's %q", out, "started\n")
+			}
+			if tt.want != "" && !strings.Contains(out, tt.want) {
+				t.Errorf("output = %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+//go:build windows
+
+package executor
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup is a no-op on Windows, where Ctrl+C already reaches every
+// process attached to the console.
+func setProcessGroup(cmd *exec.Cmd) (restore func()) {
+	return func() {}
+}
+
+// signalProcessGroup kills the command, as Windows cannot deliver SIGINT to
+// another process.
+func signalProcessGroup(cmd *exec.Cmd, sig os.Signal) {
+	if cmd.Process != nil {
+		_ = cmd.Process.Kill()
+	}
+}
+
+// killProcessGroup kills the command.
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		_ = cmd.Process.Kill()
+	}
+}
+
+// killedBySignal reports whether the command ended because it was interrupted.
+func killedBySignal(state *os.ProcessState) bool {
+	return state != nil && uint32(state.ExitCode()) == 0xC000013A // STATUS_CONTROL_C_EXIT
+}
@@ -2,7 +2,10 @@ package executor
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"unicode"
 
@@ -27,8 +30,57 @@ var (
 	wgetPostRegex      = regexp.MustCompile(`\bwget\b.*--post-file`)
 	scpRegex           = regexp.MustCompile(`\bscp\b.*@.*:`)
 	rsyncRegex         = regexp.MustCompile(`\brsync\b.*@.*:`)
-	chmodEtcRegex      = regexp.MustCompile(`\b(chmod|chown)\b.*/etc`)
-	chmodZeroRegex     = regexp.MustCompile(`\bchmod\b.*\b0+\b`)
+	// environment dumps (env, printenv, set, export -p) sent to the network
+	envExfilRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`(?:^|[;&(]|\$\(|\bsudo)\s*(?:env|printenv|set|export\s+-p|declare\s+-x)(?:\s+-\S+)*\s*\|[^;&]*\b(?:curl|wget|nc|ncat|socat|telnet|ssh)\b`),
+		regexp.MustCompile(`\b(?:curl|wget|nc|ncat|socat)\b[^;&|]*(?:\$\(|` + "`" + `)\s*(?:env|printenv|set|export\s+-p)\s*(?:\)|` + "`" + `)`),
+		regexp.MustCompile(`(?:^|[;&(])\s*(?:env|printenv|set|export\s+-p)\s*>\s*/dev/(?:tcp|udp)/`),
+	}
+	chmodEtcRegex  = regexp.MustCompile(`\b(chmod|chown)\b.*/etc`)
+	chmodZeroRegex = regexp.MustCompile(`\bchmod\b.*\b0+\b`)
+	// ssh key writes: authorized_keys or key files under any home, or anything in /root/.ssh, as the write target
+	sshKeyWriteRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`>>?\s*` + sshKeyPath),
+		regexp.MustCompile(`\btee\b(\s+-\S+)*\s+` + sshKeyPath),
+		regexp.MustCompile(`\b(cp|mv|install)\b.*\s` + sshKeyPath + `\s*($|[;&|])`),
+		regexp.MustCompile(`\bsed\b.*\s-i.*\s` + sshKeyPath),
+		regexp.MustCompile(`\bssh-keygen\b.*-f\s*` + sshKeyPath),
+	}
+	// writes to shell startup files, which run on every new shell
+	shellProfileWriteRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`>>?\s*` + shellProfilePath),
+		regexp.MustCompile(`\btee\b(\s+-\S+)*\s+` + shellProfilePath),
+		regexp.MustCompile(`\b(cp|mv|install|ln)\b.*\s` + shellProfilePath + `\s*($|[;&|])`),
+		regexp.MustCompile(`\bsed\b.*\s-i.*\s` + shellProfilePath),
+	}
+	// writes to the system-wide cron tables
+	systemCronWriteRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`>>?\s*` + systemCronPath),
+		regexp.MustCompile(`\btee\b(\s+-\S+)*\s+` + systemCronPath),
+		regexp.MustCompile(`\b(cp|mv|install|ln)\b.*\s` + systemCronPath + `\s*($|[;&|])`),
+		regexp.MustCompile(`\bsed\b.*\s-i.*\s` + systemCronPath),
+	}
+	// crontab invocations: group 1 is its arguments
+	crontabRegex = regexp.MustCompile(`(?:^|[;&|(]|\bsudo)\s*crontab\b([^;&|)]*)`)
+	// bidi controls reorder how text is displayed, so the command shown can
+	// differ from the one that runs
+	bidiControlNames = map[rune]string{
+		'\u061C': "arabic letter mark", '\u200E': "left-to-right mark", '\u200F': "right-to-left mark",
+		'\u202A': "left-to-right embedding", '\u202B': "right-to-left embedding",
+		'\u202C': "pop directional formatting", '\u202D': "left-to-right override",
+		'\u202E': "right-to-left override", '\u2066': "left-to-right isolate",
+		'\u2067': "right-to-left isolate", '\u2068': "first strong isolate",
+		'\u2069': "pop directional isolate",
+	}
+	// scripts whose letters pass for Latin ones, e.g. Cyrillic "с" for "c"
+	homoglyphScripts = []struct {
+		name  string
+		table *unicode.RangeTable
+	}{
+		{"Cyrillic", unicode.Cyrillic},
+		{"Greek", unicode.Greek},
+		{"Armenian", unicode.Armenian},
+	}
 	// privilege escalation
 	sudoRegex   = regexp.MustCompile(`\bsudo\s+`)
 	suRegex     = regexp.MustCompile(`\bsu\s+`)
@@ -37,6 +89,7 @@ var (
 	pkexecRegex = regexp.MustCompile(`\bpkexec\b`)
 	// rm patterns
 	rmRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`\brm\s+(\S+\s+)*-[a-z]*(r[a-z]*f|f[a-z]*r)`),
 		regexp.MustCompile(`\brm\s+.*-[a-z]*r[a-z]*.*-[a-z]*f`),
 		regexp.MustCompile(`\brm\s+.*--recursive.*--force`),
 		regexp.MustCompile(`\brm\s+.*--force.*--recursive`),
@@ -77,8 +130,44 @@ var (
 		regexp.MustCompile(`\bnc\b.*-l.*-e`),
 		regexp.MustCompile(`\bncat\b.*--exec`),
 	}
+	caseStatementRegex = regexp.MustCompile(`\bcase\b.*\bin\b`)
+	// kill/pkill/killall invocations: group 1 is the tool, group 2 its arguments
+	killCommandRegex = regexp.MustCompile(`(?:^|[;&|(]|\bsudo)\s*(kill|pkill|killall|killall5)\b([^;&|)]*)`)
+	// processes whose termination takes down the session or the whole system
+	criticalProcessNames = map[string]bool{
+		"init": true, "systemd": true, "launchd": true, "sshd": true, "dbus-daemon": true,
+		"systemd-journald": true, "systemd-logind": true, "xorg": true, "wayland": true,
+		"gnome-shell": true, "kwin": true, "loginwindow": true, "windowserver": true,
+		"explorer.exe": true, "csrss.exe": true, "wininit.exe": true, "lsass.exe": true,
+	}
+	// download targets: the captured group is the file (or directory for wget -P) written by curl/wget.
+	// They match the command before lowercasing, since the flags are case-sensitive: curl -o names
+	// the file while curl -O saves to the working directory, and wget -O names the file while
+	// wget -o names its log.
+	downloadTargetRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`\bcurl\b[^|;&]*?\s(?:-[a-zA-Z]*o\s*|--output[\s=])(\S+)`),
+		regexp.MustCompile(`\bwget\b[^|;&]*?\s(?:-[a-zA-Z]*O\s*|--output-document[\s=])(\S+)`),
+		regexp.MustCompile(`\b(?:curl|wget)\b[^|;&]*?>\s*(\S+)`),
+	}
+	downloadDirRegex = regexp.MustCompile(`\bwget\b[^|;&]*?\s(?:-[a-zA-Z]*P\s*|--directory-prefix[\s=])(\S+)`)
+	chmodExecRegex   = regexp.MustCompile(`\bchmod\b\s+(-\S+\s+)*([ugoa]*\+[rw]*x|0?[0-7]?[1357][0-7]{2})\b`)
+	// well-known executable directories, checked in addition to $PATH
+	executableDirs = []string{
+		"/bin", "/sbin", "/usr/bin", "/usr/sbin",
+		"/usr/local/bin", "/usr/local/sbin", "~/bin", "~/.local/bin",
+	}
 )
 
+// shellProfilePath matches bash, zsh, sh, ksh, csh and fish startup files
+// under any home spelling, and the system-wide ones in /etc.
+const shellProfilePath = `(?:\S*/)?(?:\.(?:bashrc|bash_profile|bash_login|bash_logout|profile|zshrc|zshenv|zprofile|zlogin|kshrc|cshrc|tcshrc)|\.config/fish/config\.fish)\b\S*|/etc/(?:profile|bash\.bashrc|zshrc|zsh/zshrc|environment)\b\S*`
+
+// systemCronPath matches /etc/crontab, /etc/cron.d and friends, and the cron spool.
+const systemCronPath = `(?:/etc/cron|/var/spool/cron)\S*`
+
+// sshKeyPath matches SSH key locations under any home spelling (~, $home, /home/<user>, /root).
+const sshKeyPath = `\S*(?:\.ssh/(?:authorized_keys2?|id_[\w.-]+)|/root/\.ssh)\S*`
+
 type RiskLevel int
 
 const (
@@ -89,9 +178,28 @@ const (
 	RiskCritical
 )
 
+// RiskAssessment marshals to JSON as {"level":"High","reasons":[...]}.
 type RiskAssessment struct {
-	Level   RiskLevel
-	Reasons []string
+	Level   RiskLevel `json:"level"`
+	Reasons []string  `json:"reasons"`
+}
+
+// Finding is one reason the risk check found, rated when it is detected so
+// rewording a reason never changes its severity.
+type Finding struct {
+	Reason string    `json:"reason"`
+	Level  RiskLevel `json:"level"`
+}
+
+// DetectOptions configures Detect. The zero value applies the built-in
+// checks to a command that runs without --sudo.
+type DetectOptions struct {
+	// Sudo means oneliner itself prepends sudo (--sudo), so an explicit sudo
+	// in the command is not reported as privilege escalation.
+	Sudo bool
+	// Config supplies blacklisted_binaries, allowlisted_binaries and
+	// custom_risk_patterns; nil skips them.
+	Config *config.Config
 }
 
 // Normalized command for pattern matching (lowercase, collapsed whitespace)
@@ -102,42 +210,83 @@ func normalizeCommand(cmd string) string {
 }
 
 // Check for command obfuscation techniques
-func detectObfuscation(cmd string) []string {
-	var issues []string
+func detectObfuscation(cmd string) []Finding {
+	var issues []Finding
 
 	// Hex encoding
 	if hexEncodeRegex.MatchString(cmd) {
-		issues = append(issues, "hex-encoded characters detected (possible obfuscation)")
+		issues = append(issues, Finding{"hex-encoded characters detected (possible obfuscation)", RiskLow})
 	}
 
 	// Base64
 	if base64Regex.MatchString(cmd) {
-		issues = append(issues, "base64 encoding/decoding detected (possible obfuscation)")
+		issues = append(issues, Finding{"base64 encoding/decoding detected (possible obfuscation)", RiskLow})
 	}
 
 	// Eval constructs
 	if evalRegex.MatchString(cmd) {
-		issues = append(issues, "eval/exec detected (dynamic code execution)")
+		issues = append(issues, Finding{"eval/exec detected (dynamic code execution)", RiskLow})
 	}
 
 	// Reverse operations
 	if revRegex.MatchString(cmd) {
-		issues = append(issues, "reverse command detected (possible obfuscation)")
+		issues = append(issues, Finding{"reverse command detected (possible obfuscation)", RiskLow})
 	}
 
 	// Excessive escaping
 	escapeCount := strings.Count(cmd, "\\")
 	quoteCount := strings.Count(cmd, `"`) + strings.Count(cmd, "'")
 	if escapeCount > 5 || quoteCount > 6 {
-		issues = append(issues, "excessive escaping/quoting detected")
+		issues = append(issues, Finding{"excessive escaping/quoting detected", RiskLow})
+	}
+
+	return issues
+}
+
+// Check for Unicode tricks that make a command look like something else:
+// bidi controls that reorder the displayed text, and words mixing Latin
+// letters with lookalikes from another script, e.g. "сurl" with a Cyrillic "с"
+func detectUnicodeSpoofing(cmd string) []Finding {
+	var issues []Finding
+
+	var bidi []string
+	seen := make(map[rune]bool)
+	for _, r := range cmd {
+		if name, ok := bidiControlNames[r]; ok && !seen[r] {
+			seen[r] = true
+			bidi = append(bidi, fmt.Sprintf("U+%04X (%s)", r, name))
+		}
+	}
+	if len(bidi) > 0 {
+		issues = append(issues, Finding{"bidi control characters can disguise the real command: " + strings.Join(bidi, ", "), RiskHigh})
+	}
+
+	for _, token := range strings.Fields(cmd) {
+		hasLatin := false
+		for _, r := range token {
+			if unicode.Is(unicode.Latin, r) {
+				hasLatin = true
+				break
+			}
+		}
+		if !hasLatin {
+			continue
+		}
+
+		for _, script := range homoglyphScripts {
+			if strings.IndexFunc(token, func(r rune) bool { return unicode.Is(script.table, r) }) >= 0 {
+				issues = append(issues, Finding{fmt.Sprintf("mixes Latin and %s letters in %q (possible homoglyph spoofing)", script.name, token), RiskHigh})
+				break
+			}
+		}
 	}
 
 	return issues
 }
 
 // Check for privilege escalation
-func detectPrivilegeEscalation(cmd string, intentionalSudo bool) []string {
-	var issues []string
+func detectPrivilegeEscalation(cmd string, intentionalSudo bool) []Finding {
+	var issues []Finding
 
 	// If sudo was intentionally added via --sudo flag, skip sudo checks
 	if intentionalSudo {
@@ -160,7 +309,7 @@ func detectPrivilegeEscalation(cmd string, intentionalSudo bool) []string {
 
 	for _, p := range patterns {
 		if p.pattern.MatchString(normalized) {
-			issues = append(issues, p.desc)
+			issues = append(issues, Finding{p.desc, RiskMedium})
 		}
 	}
 
@@ -168,8 +317,8 @@ func detectPrivilegeEscalation(cmd string, intentionalSudo bool) []string {
 }
 
 // Check for destructive file operations
-func detectDestructiveFileOps(cmd string) []string {
-	var issues []string
+func detectDestructiveFileOps(cmd string) []Finding {
+	var issues []Finding
 	normalized := normalizeCommand(cmd)
 
 	// rm variations
@@ -179,14 +328,14 @@ func detectDestructiveFileOps(cmd string) []string {
 			foundDanger := false
 			for _, pathRe := range dangerousPathRegexes {
 				if pathRe.MatchString(normalized) {
-					issues = append(issues, "destructive rm command targeting critical path")
+					issues = append(issues, Finding{"destructive rm command targeting critical path", RiskCritical})
 					foundDanger = true
 					break
 				}
 			}
 
 			if !foundDanger {
-				issues = append(issues, "destructive rm -rf detected (verify target path)")
+				issues = append(issues, Finding{"destructive rm -rf detected (verify target path)", RiskHigh})
 			}
 			break
 		}
@@ -194,25 +343,25 @@ func detectDestructiveFileOps(cmd string) []string {
 
 	// find -delete
 	if findDeleteRegex.MatchString(normalized) {
-		issues = append(issues, "find -delete can remove many files (potentially destructive)")
+		issues = append(issues, Finding{"find -delete can remove many files (potentially destructive)", RiskHigh})
 	}
 
 	// shred
 	if shredRegex.MatchString(normalized) {
-		issues = append(issues, "shred detected (secure file deletion, unrecoverable)")
+		issues = append(issues, Finding{"shred detected (secure file deletion, unrecoverable)", RiskHigh})
 	}
 
 	// truncate
 	if truncateRegex.MatchString(normalized) {
-		issues = append(issues, "truncate to zero detected (data loss)")
+		issues = append(issues, Finding{"truncate to zero detected (data loss)", RiskLow})
 	}
 
 	return issues
 }
 
 // Check for disk/partition operations
-func detectDiskOperations(cmd string) []string {
-	var issues []string
+func detectDiskOperations(cmd string) []Finding {
+	var issues []Finding
 	normalized := normalizeCommand(cmd)
 
 	for _, op := range diskOpRegexes {
@@ -227,7 +376,7 @@ func detectDiskOperations(cmd string) []string {
 				// Extract a more meaningful description from the regex pattern
 				desc = "disk/partition operation detected"
 			}
-			issues = append(issues, desc)
+			issues = append(issues, Finding{desc, RiskCritical})
 		}
 	}
 
@@ -235,8 +384,8 @@ func detectDiskOperations(cmd string) []string {
 }
 
 // Check for system file modifications
-func detectSystemFileModification(cmd string) []string {
-	var issues []string
+func detectSystemFileModification(cmd string) []Finding {
+	var issues []Finding
 	normalized := normalizeCommand(cmd)
 
 	// Critical files
@@ -255,16 +404,22 @@ func detectSystemFileModification(cmd string) []string {
 	writeOps := []string{`>`, `>>`, `\btee\b`, `\bsed\b.*-i`}
 
 	for _, file := range criticalFiles {
+		// the account databases hand out logins and root; the rest need care
+		level := RiskMedium
+		if file == "/etc/passwd" || file == "/etc/shadow" {
+			level = RiskCritical
+		}
+
 		for _, op := range writeOps {
 			pattern := op + `.*` + regexp.QuoteMeta(file)
 			if matched, _ := regexp.MatchString(pattern, normalized); matched {
-				issues = append(issues, fmt.Sprintf("modification to critical system file: %s", file))
+				issues = append(issues, Finding{fmt.Sprintf("modification to critical system file: %s", file), level})
 				break
 			}
 			// Also check reverse (file ... op)
 			reversePattern := regexp.QuoteMeta(file) + `.*` + op
 			if matched, _ := regexp.MatchString(reversePattern, normalized); matched {
-				issues = append(issues, fmt.Sprintf("modification to critical system file: %s", file))
+				issues = append(issues, Finding{fmt.Sprintf("modification to critical system file: %s", file), level})
 				break
 			}
 		}
@@ -272,72 +427,339 @@ func detectSystemFileModification(cmd string) []string {
 
 	// Chmod/chown on system dirs
 	if chmodEtcRegex.MatchString(normalized) {
-		issues = append(issues, "permission change on /etc directory")
+		issues = append(issues, Finding{"permission change on /etc directory", RiskLow})
 	}
 
 	if chmodZeroRegex.MatchString(normalized) {
-		issues = append(issues, "chmod removing all permissions (files will be inaccessible)")
+		issues = append(issues, Finding{"chmod removing all permissions (files will be inaccessible)", RiskLow})
+	}
+
+	// Shell startup files and cron are common persistence vectors
+	for _, r := range shellProfileWriteRegexes {
+		if r.MatchString(normalized) {
+			issues = append(issues, Finding{"modifies a shell startup file (runs in every new shell)", RiskMedium})
+			break
+		}
+	}
+
+	for _, r := range systemCronWriteRegexes {
+		if r.MatchString(normalized) {
+			issues = append(issues, Finding{"writes to system cron (runs as root on a schedule)", RiskHigh})
+			break
+		}
+	}
+
+	for _, m := range crontabRegex.FindAllStringSubmatch(normalized, -1) {
+		args := strings.Fields(m[1])
+		switch {
+		case slices.Contains(args, "-r"):
+			issues = append(issues, Finding{"removes every crontab entry (crontab -r)", RiskHigh})
+		case slices.Contains(args, "-l"):
+			// listing only
+		default:
+			// -e, - (stdin) or a file all replace the crontab
+			issues = append(issues, Finding{"modifies the crontab (scheduled persistence)", RiskMedium})
+		}
 	}
 
 	return issues
 }
 
+// Check for writes to SSH keys or authorized_keys (common persistence vector)
+func detectSSHKeyModification(cmd string) []Finding {
+	var issues []Finding
+	normalized := normalizeCommand(cmd)
+
+	for _, r := range sshKeyWriteRegexes {
+		if r.MatchString(normalized) {
+			issues = append(issues, Finding{"modifies SSH keys/authorized_keys", RiskHigh})
+			break
+		}
+	}
+
+	return issues
+}
+
+// Check for unbalanced quotes, backticks or parentheses, which make sh -c
+// wait for more input or fail cryptically
+func detectUnbalancedQuoting(cmd string) []Finding {
+	var issues []Finding
+	inSingle, inDouble, inBacktick, escaped := false, false, false, false
+	depth := 0
+	strayClose := false
+
+	for _, r := range cmd {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && !inSingle:
+			escaped = true
+		case r == '\'' && !inDouble && !inBacktick:
+			inSingle = !inSingle
+		case r == '"' && !inSingle:
+			inDouble = !inDouble
+		case r == '`' && !inSingle:
+			inBacktick = !inBacktick
+		case r == '(' && !inSingle && !inDouble:
+			depth++
+		case r == ')' && !inSingle && !inDouble:
+			if depth == 0 {
+				strayClose = true
+			} else {
+				depth--
+			}
+		}
+	}
+
+	if inSingle || inDouble || inBacktick {
+		issues = append(issues, Finding{"command appears to have unbalanced quotes", RiskMedium})
+	}
+
+	// case patterns like "a)" legitimately close parens that were never opened
+	if (depth > 0 || strayClose) && !caseStatementRegex.MatchString(cmd) {
+		issues = append(issues, Finding{"command appears to have unbalanced parentheses", RiskMedium})
+	}
+
+	return issues
+}
+
+// splitCommandSegments splits cmd on top-level ;, &, &&, || and newlines.
+// Separators inside quotes, backticks or parentheses (subshells, $(...))
+// are left alone, as are the & of redirections like 2>&1 and &>.
+func splitCommandSegments(cmd string) []string {
+	var segments []string
+	var current strings.Builder
+	inSingle, inDouble, inBacktick, escaped := false, false, false, false
+	depth := 0
+
+	flush := func() {
+		if segment := strings.TrimSpace(current.String()); segment != "" {
+			segments = append(segments, segment)
+		}
+		current.Reset()
+	}
+
+	runes := []rune(cmd)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		next := rune(0)
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+
+		topLevel := !inSingle && !inDouble && !inBacktick && depth == 0
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && !inSingle:
+			escaped = true
+		case r == '\'' && !inDouble && !inBacktick:
+			inSingle = !inSingle
+		case r == '"' && !inSingle:
+			inDouble = !inDouble
+		case r == '`' && !inSingle:
+			inBacktick = !inBacktick
+		case r == '(' && !inSingle && !inDouble:
+			depth++
+		case r == ')' && !inSingle && !inDouble && depth > 0:
+			depth--
+		case topLevel && (r == ';' || r == '\n'):
+			flush()
+			continue
+		case topLevel && (r == '&' || r == '|') && next == r:
+			flush()
+			i++
+			continue
+		case topLevel && r == '&' && next != '>' && (i == 0 || (runes[i-1] != '>' && runes[i-1] != '<')):
+			flush()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	flush()
+
+	return segments
+}
+
 // Check for network/download operations
-func detectNetworkOperations(cmd string) []string {
-	var issues []string
+func detectNetworkOperations(cmd string) []Finding {
+	var issues []Finding
 	normalized := normalizeCommand(cmd)
 
 	for _, p := range networkRegexes {
 		if p.MatchString(normalized) {
 			switch {
 			case p == networkRegexes[0]:
-				issues = append(issues, "piping download directly to shell (dangerous)")
+				issues = append(issues, Finding{"piping download directly to shell (dangerous)", RiskHigh})
 			case p == networkRegexes[1]:
-				issues = append(issues, "piping download to bash")
+				issues = append(issues, Finding{"piping download to bash", RiskHigh})
 			case p == networkRegexes[2]:
-				issues = append(issues, "piping download to python")
+				issues = append(issues, Finding{"piping download to python", RiskHigh})
 			case p == networkRegexes[3]:
-				issues = append(issues, "download and execute pattern")
+				issues = append(issues, Finding{"download and execute pattern", RiskHigh})
 			case p == networkRegexes[4]:
-				issues = append(issues, "netcat with command execution")
+				issues = append(issues, Finding{"netcat with command execution", RiskHigh})
 			case p == networkRegexes[5]:
-				issues = append(issues, "ncat with command execution")
+				issues = append(issues, Finding{"ncat with command execution", RiskHigh})
 			default:
-				issues = append(issues, "network operation detected")
+				issues = append(issues, Finding{"network operation detected", RiskLow})
 			}
 		}
 	}
 
+	if targetsExecutableDir(whitespaceRegex.ReplaceAllString(strings.TrimSpace(cmd), " ")) {
+		if chmodExecRegex.MatchString(normalized) {
+			issues = append(issues, Finding{"downloads a binary into PATH and makes it executable (supply-chain risk)", RiskHigh})
+		} else {
+			issues = append(issues, Finding{"downloads a file into an executable PATH directory (supply-chain risk)", RiskHigh})
+		}
+	}
+
 	return issues
 }
 
+// targetsExecutableDir reports whether a curl/wget download in the command
+// (whitespace collapsed, case kept) writes into a directory that is on PATH or
+// a well-known bin directory.
+func targetsExecutableDir(cmd string) bool {
+	dirs := make(map[string]bool)
+	for _, d := range executableDirs {
+		dirs[expandHome(d)] = true
+	}
+	for _, d := range filepath.SplitList(os.Getenv("PATH")) {
+		if d != "" {
+			dirs[strings.ToLower(filepath.Clean(d))] = true
+		}
+	}
+
+	for _, r := range downloadTargetRegexes {
+		for _, m := range r.FindAllStringSubmatch(cmd, -1) {
+			if dirs[filepath.Dir(expandHome(strings.ToLower(m[1])))] {
+				return true
+			}
+		}
+	}
+
+	for _, m := range downloadDirRegex.FindAllStringSubmatch(cmd, -1) {
+		if dirs[expandHome(strings.ToLower(m[1]))] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// expandHome resolves ~ and $home prefixes in a normalized path and cleans it.
+func expandHome(path string) string {
+	path = strings.Trim(path, `"'`)
+	home, err := os.UserHomeDir()
+	if err == nil {
+		home = strings.ToLower(home)
+		for _, prefix := range []string{"~", "$home", "${home}"} {
+			if path == prefix || strings.HasPrefix(path, prefix+"/") {
+				path = home + path[len(prefix):]
+				break
+			}
+		}
+	}
+	return filepath.Clean(path)
+}
+
 // Check for fork bombs and resource exhaustion
-func detectResourceExhaustion(cmd string) []string {
-	var issues []string
+func detectResourceExhaustion(cmd string) []Finding {
+	var issues []Finding
 
 	// Classic fork bomb
 	if forkBombRegex.MatchString(cmd) {
-		issues = append(issues, "fork bomb detected (will crash system)")
+		issues = append(issues, Finding{"fork bomb detected (will crash system)", RiskCritical})
 	}
 
 	// Infinite loops
 	if infiniteLoopRegex.MatchString(cmd) {
 		if !sleepWaitReadRegex.MatchString(cmd) {
-			issues = append(issues, "infinite loop without delay (potential resource exhaustion)")
+			issues = append(issues, Finding{"infinite loop without delay (potential resource exhaustion)", RiskLow})
 		}
 	}
 
 	// Massive file creation
 	if ddLargeRegex.MatchString(cmd) {
-		issues = append(issues, "large file creation with dd")
+		issues = append(issues, Finding{"large file creation with dd", RiskLow})
+	}
+
+	return issues
+}
+
+// Check for kill/pkill/killall aimed at init, every process, critical system
+// processes or broad name patterns. Killing a job (%1) or one specific PID is fine.
+func detectProcessKilling(cmd string) []Finding {
+	var issues []Finding
+	normalized := normalizeCommand(cmd)
+
+	for _, m := range killCommandRegex.FindAllStringSubmatch(normalized, -1) {
+		tool, args := m[1], strings.Fields(m[2])
+
+		if tool == "killall5" {
+			issues = append(issues, Finding{"process termination of every process (killall5)", RiskHigh})
+			continue
+		}
+
+		forced := false
+		var targets, users []string
+		for i := 0; i < len(args); i++ {
+			arg := args[i]
+			switch {
+			case arg == "-9" || arg == "-kill" || arg == "-sigkill" || arg == "--signal=9" || arg == "--signal=kill":
+				forced = true
+			case arg == "-s" || arg == "-n" || arg == "--signal":
+				if i+1 < len(args) {
+					i++
+					if sig := strings.TrimPrefix(args[i], "sig"); sig == "9" || sig == "kill" {
+						forced = true
+					}
+				}
+			case arg == "-u" || arg == "-U" || arg == "--user":
+				if i+1 < len(args) {
+					i++
+					users = append(users, args[i])
+				}
+			case tool != "kill" && (arg == "-g" || arg == "-G" || arg == "-P" || arg == "-t" || arg == "-o" || arg == "-y"):
+				i++ // option value, not a target
+			case tool == "kill" && arg == "-1" && i > 0:
+				// after a signal, -1 is the "every process" pid
+				targets = append(targets, arg)
+			case strings.HasPrefix(arg, "-"):
+				// other flags
+			default:
+				targets = append(targets, strings.Trim(arg, `"'`))
+			}
+		}
+
+		for _, user := range users {
+			issues = append(issues, Finding{fmt.Sprintf("broad kill: every process owned by user %s", user), RiskMedium})
+		}
+
+		for _, target := range targets {
+			switch {
+			case tool == "kill" && target == "1":
+				issues = append(issues, Finding{"process termination of init (PID 1)", RiskHigh})
+			case tool == "kill" && target == "-1":
+				issues = append(issues, Finding{"process termination of every process (kill -1)", RiskHigh})
+			case tool == "kill":
+				// a specific pid or a job spec like %1
+			case criticalProcessNames[filepath.Base(target)]:
+				issues = append(issues, Finding{fmt.Sprintf("process termination of system-critical process: %s", target), RiskHigh})
+			case forced:
+				issues = append(issues, Finding{fmt.Sprintf("broad kill: SIGKILL (-9) to every process matching '%s'", target), RiskMedium})
+			}
+		}
 	}
 
 	return issues
 }
 
 // Check for data exfiltration patterns
-func detectDataExfiltration(cmd string) []string {
-	var issues []string
+func detectDataExfiltration(cmd string) []Finding {
+	var issues []Finding
 	normalized := normalizeCommand(cmd)
 
 	patterns := []struct {
@@ -353,112 +775,162 @@ func detectDataExfiltration(cmd string) []string {
 
 	for _, p := range patterns {
 		if p.pattern.MatchString(normalized) {
-			issues = append(issues, p.desc)
+			issues = append(issues, Finding{p.desc, RiskLow})
+		}
+	}
+
+	for _, re := range envExfilRegexes {
+		if re.MatchString(normalized) {
+			issues = append(issues, Finding{"may exfiltrate environment variables (secrets)", RiskHigh})
+			break
 		}
 	}
 
 	return issues
 }
 
-// Main assessment function
-func AssessCommandRisk(command string, usedSudoFlag bool) RiskAssessment {
+// Detect runs every risk check on command and returns what it found, each
+// reason once, in a stable order. It does no I/O, so the caller passes the
+// config it already loaded. A command without findings is safe as far as
+// the heuristics can tell, which is not a guarantee.
+func Detect(command string, opts DetectOptions) []Finding {
 	trimmed := strings.TrimSpace(command)
-	assessment := RiskAssessment{
-		Level:   RiskNone,
-		Reasons: []string{},
-	}
-
 	if trimmed == "" {
-		assessment.Reasons = append(assessment.Reasons, "empty command")
-		return assessment
+		return []Finding{{"empty command", RiskNone}}
 	}
 
 	// Control character check
 	for _, r := range trimmed {
+		if _, ok := bidiControlNames[r]; ok {
+			continue // reported by name in detectUnicodeSpoofing
+		}
 		if r == '\x00' || (!unicode.IsPrint(r) && !unicode.IsSpace(r)) {
-			assessment.Reasons = append(assessment.Reasons, "contains invalid control characters")
-			assessment.Level = RiskHigh
-			return assessment
+			return []Finding{{"contains invalid control characters", RiskHigh}}
 		}
 	}
 
 	// Run all detection functions
-	var allIssues [][]string
+	var allIssues [][]Finding
 
+	allIssues = append(allIssues, detectUnbalancedQuoting(trimmed))
 	allIssues = append(allIssues, detectObfuscation(trimmed))
-	allIssues = append(allIssues, detectPrivilegeEscalation(trimmed, usedSudoFlag))
-	allIssues = append(allIssues, detectDestructiveFileOps(trimmed))
-	allIssues = append(allIssues, detectDiskOperations(trimmed))
-	allIssues = append(allIssues, detectSystemFileModification(trimmed))
+	allIssues = append(allIssues, detectUnicodeSpoofing(trimmed))
+
+	// Detectors that relate a command to its target paths run on each chained
+	// segment, so "cd /etc && rm -rf build" is not read as rm aimed at /etc
+	// and "cat /etc/hosts; echo ok > out.txt" is not read as writing /etc/hosts.
+	for _, segment := range splitCommandSegments(trimmed) {
+		allIssues = append(allIssues, detectPrivilegeEscalation(segment, opts.Sudo))
+		allIssues = append(allIssues, detectDestructiveFileOps(segment))
+		allIssues = append(allIssues, detectDiskOperations(segment))
+		allIssues = append(allIssues, detectSystemFileModification(segment))
+		allIssues = append(allIssues, detectSSHKeyModification(segment))
+	}
+
+	// These look for patterns that span segments on purpose, e.g. download
+	// && sh, fork bombs and while ...; done loops, so they see the whole command.
 	allIssues = append(allIssues, detectNetworkOperations(trimmed))
 	allIssues = append(allIssues, detectResourceExhaustion(trimmed))
+	allIssues = append(allIssues, detectProcessKilling(trimmed))
 	allIssues = append(allIssues, detectDataExfiltration(trimmed))
 
-	// Flatten and deduplicate
-	seen := make(map[string]bool)
+	// custom_risk_patterns count like the built-in detectors
+	cfg := opts.Config
+	if cfg != nil {
+		allIssues = append(allIssues, detectCustomPatterns(trimmed, cfg.CustomRiskPatterns))
+	}
+
+	// Flatten and deduplicate; a reason found twice keeps its higher level
+	findings := []Finding{}
+	index := make(map[string]int)
 	for _, issues := range allIssues {
 		for _, issue := range issues {
-			if !seen[issue] {
-				seen[issue] = true
-				assessment.Reasons = append(assessment.Reasons, issue)
+			if i, ok := index[issue.Reason]; ok {
+				findings[i].Level = max(findings[i].Level, issue.Level)
+				continue
 			}
+			index[issue.Reason] = len(findings)
+			findings = append(findings, issue)
 		}
 	}
 
-	// Check for blacklisted binaries from config and mark critical if found
+	// Check for blacklisted binaries from config and mark critical if found.
+	// allowlisted_binaries wins over the blacklist, but only for this check:
+	// an allowlisted curl is fine, while curl | sh is still caught above.
 	normalized := normalizeCommand(trimmed)
-	if cfg, err := config.Load(""); err == nil {
-		if len(cfg.BlacklistedBinaries) > 0 {
-			for _, bin := range cfg.BlacklistedBinaries {
-				pattern := `\b` + regexp.QuoteMeta(strings.ToLower(bin)) + `\b`
-				if matched, _ := regexp.MatchString(pattern, normalized); matched {
-					assessment.Reasons = append(assessment.Reasons, fmt.Sprintf("executes blacklisted binary: %s", bin))
-					assessment.Level = RiskCritical
-					return assessment
-				}
+	if cfg != nil {
+		for _, bin := range cfg.BlacklistedBinaries {
+			if allowlisted(bin, cfg.AllowlistedBinaries) {
+				continue
+			}
+			pattern := `\b` + regexp.QuoteMeta(strings.ToLower(bin)) + `\b`
+			if matched, _ := regexp.MatchString(pattern, normalized); matched {
+				return append(findings, Finding{fmt.Sprintf("executes blacklisted binary: %s", bin), RiskCritical})
 			}
 		}
 	}
 
-	// Determine risk level based on issues found
-	if len(assessment.Reasons) == 0 {
-		assessment.Level = RiskNone
-	} else {
-		// Calculate risk based on specific patterns
-		criticalKeywords := []string{"fork bomb", "disk", "partition", "/etc/passwd", "/etc/shadow", "crash system"}
-		highKeywords := []string{"destructive", "rm -rf", "overwrite", "erase", "unrecoverable"}
-		mediumKeywords := []string{"sudo", "privilege", "critical"}
-
-		for _, reason := range assessment.Reasons {
-			lowerReason := strings.ToLower(reason)
-
-			for _, kw := range criticalKeywords {
-				if strings.Contains(lowerReason, kw) {
-					assessment.Level = RiskCritical
-					goto done
-				}
-			}
+	return findings
+}
 
-			for _, kw := range highKeywords {
-				if strings.Contains(lowerReason, kw) && assessment.Level < RiskHigh {
-					assessment.Level = RiskHigh
-				}
-			}
+// AssessCommandRisk rates command by the built-in detectors plus the
+// blacklist, allowlist and custom patterns of cfg: the reasons Detect finds,
+// at the level of the worst one. A nil cfg applies the built-in checks only.
+func AssessCommandRisk(command string, usedSudoFlag bool, cfg *config.Config) RiskAssessment {
+	assessment := RiskAssessment{
+		Level:   RiskNone,
+		Reasons: []string{},
+	}
+	for _, f := range Detect(command, DetectOptions{Sudo: usedSudoFlag, Config: cfg}) {
+		assessment.Reasons = append(assessment.Reasons, f.Reason)
+		assessment.Level = max(assessment.Level, f.Level)
+	}
+	return assessment
+}
 
-			for _, kw := range mediumKeywords {
-				if strings.Contains(lowerReason, kw) && assessment.Level < RiskMedium {
-					assessment.Level = RiskMedium
-				}
-			}
+// detectCustomPatterns evaluates the user's custom_risk_patterns against the
+// command as written. Patterns are validated when the config is loaded; any
+// that still fail to compile are skipped.
+func detectCustomPatterns(cmd string, patterns []config.RiskPattern) []Finding {
+	var matches []Finding
+	for _, p := range patterns {
+		re, err := regexp.Compile(p.Regex)
+		if err != nil || !re.MatchString(cmd) {
+			continue
 		}
 
-		if assessment.Level == RiskNone {
-			assessment.Level = RiskLow
+		reason := strings.TrimSpace(p.Description)
+		if reason == "" {
+			reason = "matches custom risk pattern: " + p.Regex
 		}
+		matches = append(matches, Finding{reason, parseRiskLevel(p.Level)})
 	}
+	return matches
+}
 
-done:
-	return assessment
+// parseRiskLevel maps a custom_risk_patterns level to a RiskLevel. Unknown
+// levels count as high rather than being dropped.
+func parseRiskLevel(level string) RiskLevel {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "low":
+		return RiskLow
+	case "medium":
+		return RiskMedium
+	case "critical":
+		return RiskCritical
+	default:
+		return RiskHigh
+	}
+}
+
+// allowlisted reports whether bin appears in allowlist, ignoring case.
+func allowlisted(bin string, allowlist []string) bool {
+	for _, allowed := range allowlist {
+		if strings.EqualFold(strings.TrimSpace(allowed), strings.TrimSpace(bin)) {
+			return true
+		}
+	}
+	return false
 }
 
 // Get risk level as string
@@ -478,3 +950,22 @@ func (r RiskLevel) String() string {
 		return "Unknown"
 	}
 }
+
+// MarshalText encodes the level by name, so JSON output reads "High" rather than 3.
+func (r RiskLevel) MarshalText() ([]byte, error) {
+	if r < RiskNone || r > RiskCritical {
+		return nil, fmt.Errorf("invalid risk level %d", int(r))
+	}
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText parses a level name as written by MarshalText, ignoring case.
+func (r *RiskLevel) UnmarshalText(text []byte) error {
+	for level := RiskNone; level <= RiskCritical; level++ {
+		if strings.EqualFold(string(text), level.String()) {
+			*r = level
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown risk level %q", text)
+}
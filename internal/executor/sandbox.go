@@ -0,0 +1,70 @@
+package executor
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/dorochadev/oneliner/config"
+)
+
+// sandboxArgs splits sandbox_command on whitespace (e.g. "firejail --private")
+// and checks that the tool is installed. It returns nil when no sandbox is set.
+func sandboxArgs(cfg *config.Config) ([]string, error) {
+	if cfg == nil || runtime.GOOS == "windows" {
+		return nil, nil
+	}
+	fields := strings.Fields(cfg.SandboxCommand)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	if _, err := exec.LookPath(fields[0]); err != nil {
+		return nil, fmt.Errorf("sandbox tool %s not found", fields[0])
+	}
+	return fields, nil
+}
+
+// shellArgv returns the full argv used to run command with shell, or sh (cmd
+// on Windows) when shell is empty, wrapped in the sandbox if any.
+func shellArgv(sandbox []string, shell, command string) []string {
+	return append(append([]string{}, sandbox...), shellInvocation(shell, command)...)
+}
+
+// shellInvocation returns the argv that makes shell run command, using the
+// flag each shell family expects.
+func shellInvocation(shell, command string) []string {
+	if shell == "" {
+		if runtime.GOOS == "windows" {
+			return []string{"cmd", "/C", command}
+		}
+		return []string{"sh", "-c", command}
+	}
+
+	switch strings.TrimSuffix(strings.ToLower(filepath.Base(shell)), ".exe") {
+	case "cmd":
+		return []string{shell, "/C", command}
+	case "powershell", "pwsh":
+		return []string{shell, "-NoProfile", "-Command", command}
+	default:
+		// sh, bash, zsh, fish, ksh, dash, nu and friends
+		return []string{shell, "-c", command}
+	}
+}
+
+// formatInvocation renders argv the way it would be typed in a shell.
+func formatInvocation(argv []string) string {
+	parts := make([]string, len(argv))
+	for i, arg := range argv {
+		parts[i] = shellQuote(arg)
+	}
+	return strings.Join(parts, " ")
+}
+
+func shellQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`|&;<>()*?[]{}~!#") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
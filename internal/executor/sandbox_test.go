@@ -0,0 +1,103 @@
+package executor
+
+import (
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/dorochadev/oneliner/config"
+)
+
+func TestSandboxArgs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sandbox_command is ignored on Windows")
+	}
+
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+		wantErr bool
+	}{
+		{"unset", "", nil, false},
+		{"blank", "   ", nil, false},
+		{"tool with flags", "  env  -i\tPATH=/usr/bin ", []string{"env", "-i", "PATH=/usr/bin"}, false},
+		{"missing tool", "oneliner-no-such-sandbox --private", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.SandboxCommand = tt.command
+			got, err := sandboxArgs(cfg)
+			if (err != nil) != tt.wantErr || !slices.Equal(got, tt.want) {
+				t.Errorf("sandboxArgs(%q) = %q, %v; want %q, error %v", tt.command, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+
+	if got, err := sandboxArgs(nil); got != nil || err != nil {
+		t.Errorf("sandboxArgs(nil) = %q, %v", got, err)
+	}
+}
+
+func TestShellArgv(t *testing.T) {
+	tests := []struct {
+		name    string
+		sandbox []string
+		shell   string
+		want    []string
+	}{
+		{"bash", nil, "/bin/bash", []string{"/bin/bash", "-c", "ls -la"}},
+		{"fish", nil, "fish", []string{"fish", "-c", "ls -la"}},
+		{"powershell", nil, "pwsh", []string{"pwsh", "-NoProfile", "-Command", "ls -la"}},
+		{"powershell exe", nil, "PowerShell.exe", []string{"PowerShell.exe", "-NoProfile", "-Command", "ls -la"}},
+		{"cmd", nil, "cmd.exe", []string{"cmd.exe", "/C", "ls -la"}},
+		{"sandboxed", []string{"firejail", "--private"}, "/bin/zsh", []string{"firejail", "--private", "/bin/zsh", "-c", "ls -la"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shellArgv(tt.sandbox, tt.shell, "ls -la"); !slices.Equal(got, tt.want) {
+				t.Errorf("shellArgv(%q, %q) = %q, want %q", tt.sandbox, tt.shell, got, tt.want)
+			}
+		})
+	}
+
+	if runtime.GOOS != "windows" {
+		want := []string{"bwrap", "--ro-bind", "/", "/", "sh", "-c", "ls -la"}
+		if got := shellArgv([]string{"bwrap", "--ro-bind", "/", "/"}, "", "ls -la"); !slices.Equal(got, want) {
+			t.Errorf("shellArgv with the default shell = %q, want %q", got, want)
+		}
+	}
+
+	// the sandbox slice is shared with the caller and must not be written to
+	sandbox := make([]string, 1, 4)
+	sandbox[0] = "firejail"
+	first := shellArgv(sandbox, "bash", "echo one")
+	shellArgv(sandbox, "bash", "echo two")
+	if first[len(first)-1] != "echo one" {
+		t.Errorf("second call overwrote the first argv: %q", first)
+	}
+}
+
+func TestFormatInvocation(t *testing.T) {
+	tests := []struct {
+		argv []string
+		want string
+	}{
+		{[]string{"firejail", "--private", "bash", "-c", "ls"}, "firejail --private bash -c ls"},
+		{[]string{"sh", "-c", "ls -la | wc -l"}, "sh -c 'ls -la | wc -l'"},
+		{[]string{"sh", "-c", "echo 'hi'"}, `sh -c 'echo '\''hi'\'''`},
+		{[]string{"sh", "-c", "rm *.log"}, "sh -c 'rm *.log'"},
+		{[]string{"env", "A=", ""}, "env A= ''"},
+		{[]string{"sh", "-c", "echo $HOME"}, "sh -c 'echo $HOME'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := formatInvocation(tt.argv); got != tt.want {
+				t.Errorf("formatInvocation(%q) = %s, want %s", tt.argv, got, tt.want)
+			}
+		})
+	}
+}
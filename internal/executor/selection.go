@@ -0,0 +1,101 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Candidate is one command offered in the selection list.
+type Candidate struct {
+	// Label says where the command came from, e.g. "A • gpt-4o".
+	Label   string
+	Command string
+	Risk    RiskLevel
+}
+
+// SelectionModel lets the user pick one of several generated commands:
+// ↑/↓ or a number selects, enter runs, c copies and esc cancels.
+type SelectionModel struct {
+	Candidates []Candidate
+	// Selected is the index of the highlighted candidate.
+	Selected int
+	Action   Action
+	// EnterLabel names what enter does in the key hint ("run" by default).
+	EnterLabel string
+}
+
+func NewSelectionModel(candidates []Candidate) SelectionModel {
+	return SelectionModel{Candidates: candidates, EnterLabel: "run"}
+}
+
+func (m SelectionModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m SelectionModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch key := keyMsg.String(); key {
+	case "ctrl+c", "esc", "q":
+		m.Action = ActionCancel
+		return m, tea.Quit
+	case "up", "k":
+		if m.Selected > 0 {
+			m.Selected--
+		}
+	case "down", "j":
+		if m.Selected < len(m.Candidates)-1 {
+			m.Selected++
+		}
+	case "enter":
+		m.Action = ActionRun
+		return m, tea.Quit
+	case "c":
+		m.Action = ActionCopy
+		return m, tea.Quit
+	default:
+		if len(key) == 1 && key[0] >= '1' && int(key[0]-'1') < len(m.Candidates) {
+			m.Selected = int(key[0] - '1')
+		}
+	}
+
+	return m, nil
+}
+
+func (m SelectionModel) View() string {
+	var b strings.Builder
+	b.WriteString("\n")
+
+	for i, c := range m.Candidates {
+		cursor := "  "
+		command := whiteStyle.Render(c.Command)
+		if i == m.Selected {
+			cursor = cyanStyle.Render("❯ ")
+			command = commandStyle.Render(c.Command)
+		}
+		b.WriteString(fmt.Sprintf("%s%d) %s %s\n", cursor, i+1, dimStyle.Render(c.Label), riskTag(c.Risk)))
+		b.WriteString(fmt.Sprintf("     %s\n\n", command))
+	}
+
+	b.WriteString(dimStyle.Render(fmt.Sprintf("  ↑/↓ select • enter %s • c copy • esc cancel", m.EnterLabel)))
+	b.WriteString("\n")
+	return b.String()
+}
+
+// riskTag renders a short risk label coloured by severity.
+func riskTag(level RiskLevel) string {
+	label := "[" + strings.ToUpper(level.String()) + " RISK]"
+	switch {
+	case level >= RiskHigh:
+		return cancelStyle.Render(label)
+	case level == RiskMedium:
+		return warningStyle.Render(label)
+	default:
+		return dimStyle.Render(label)
+	}
+}
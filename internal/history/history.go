@@ -0,0 +1,79 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Entry is one answered query. Unlike the cache, which is keyed by a hash of
+// the query and its context, history keeps the query as typed, in order.
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Query    string    `json:"query"`
+	Command  string    `json:"command"`
+	Provider string    `json:"provider"`
+	Model    string    `json:"model,omitempty"`
+	// Cached is set when the command was served from the cache.
+	Cached bool `json:"cached,omitempty"`
+}
+
+// Append adds entry to the JSON-lines history file at path, creating it if needed.
+func Append(path string, entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encoding history entry: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("creating history directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("opening history file: %w", err)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("writing history file: %w", err)
+	}
+	return file.Close()
+}
+
+// Load reads every entry at path, oldest first. A missing file is an empty
+// history; lines that fail to decode are skipped rather than failing the rest.
+func Load(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening history file: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading history file: %w", err)
+	}
+	return entries, nil
+}
+
+// Clear removes the history file. A missing file is not an error.
+func Clear(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing history file: %w", err)
+	}
+	return nil
+}
@@ -1,47 +1,181 @@
 package llm
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/dorochadev/oneliner/config"
+	promptpkg "github.com/dorochadev/oneliner/internal/prompt"
 )
 
 type LLM interface {
-	GenerateCommand(prompt string) (string, error)
+	// GenerateCommand sends prompt to the provider. Cancelling ctx aborts the
+	// request, including any retries still pending.
+	GenerateCommand(ctx context.Context, prompt string) (string, error)
 }
 
+// Usage is the token count a provider reported for one request.
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+}
+
+// UsageReporter is implemented by providers that report token usage. ok is
+// false when the last response carried no usage information.
+type UsageReporter interface {
+	LastUsage() (usage Usage, ok bool)
+}
+
+// MultiGenerator is implemented by providers that can return several
+// alternative answers to one prompt in a single request.
+type MultiGenerator interface {
+	GenerateCommands(ctx context.Context, prompt string, n int) ([]string, error)
+}
+
+// Streamer is implemented by providers that can print the answer as it
+// arrives. Callers should not draw a spinner over a streaming provider.
+type Streamer interface {
+	Streaming() bool
+}
+
+// Providers lists the llm_api values New accepts, in the order setup offers them.
+var Providers = []string{"openai", "claude", "mistral", "cohere", "gemini", "local"}
+
 func New(cfg *config.Config) (LLM, error) {
+	apiKey := cfg.APIKey
+	if strings.TrimSpace(apiKey) == "" {
+		apiKey, _ = config.APIKeyFromEnv(cfg.LLMAPI)
+	}
+
+	client, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	requestTimeout, clientTimeout := providerTimeouts(cfg)
+
 	switch cfg.LLMAPI {
 	case "openai":
 		return &OpenAI{
-			APIKey: cfg.APIKey,
-			Model:  cfg.Model,
+			APIKey:         apiKey,
+			Model:          cfg.Model,
+			Headers:        cfg.CustomHeaders,
+			MaxRetries:     cfg.MaxRetries,
+			HTTPClient:     client,
+			RequestTimeout: requestTimeout,
+			ClientTimeout:  clientTimeout,
+			BaseURL:        cfg.OpenAIBaseURL,
+			Path:           cfg.OpenAIPath,
+			Stream:         cfg.Stream,
+			Output:         os.Stdout,
+			Temperature:    cfg.Temperature,
+			TopP:           cfg.TopP,
 		}, nil
 	case "claude":
 		return &Claude{
-			APIKey:    cfg.APIKey,
-			Model:     cfg.Model,
-			MaxTokens: cfg.ClaudeMaxTokens,
+			APIKey:         apiKey,
+			Model:          cfg.Model,
+			MaxTokens:      cfg.ClaudeMaxTokens,
+			Headers:        cfg.CustomHeaders,
+			MaxRetries:     cfg.MaxRetries,
+			HTTPClient:     client,
+			RequestTimeout: requestTimeout,
+			ClientTimeout:  clientTimeout,
+			Temperature:    cfg.Temperature,
+			TopP:           cfg.TopP,
+		}, nil
+	case "mistral":
+		return &Mistral{
+			APIKey:         apiKey,
+			Model:          cfg.Model,
+			Headers:        cfg.CustomHeaders,
+			MaxRetries:     cfg.MaxRetries,
+			HTTPClient:     client,
+			RequestTimeout: requestTimeout,
+			ClientTimeout:  clientTimeout,
+			Temperature:    cfg.Temperature,
+			TopP:           cfg.TopP,
+		}, nil
+	case "cohere":
+		return &Cohere{
+			APIKey:         apiKey,
+			Model:          cfg.Model,
+			Headers:        cfg.CustomHeaders,
+			MaxRetries:     cfg.MaxRetries,
+			HTTPClient:     client,
+			RequestTimeout: requestTimeout,
+			ClientTimeout:  clientTimeout,
+		}, nil
+	case "gemini":
+		return &Gemini{
+			APIKey:         apiKey,
+			Model:          cfg.Model,
+			Headers:        cfg.CustomHeaders,
+			MaxRetries:     cfg.MaxRetries,
+			HTTPClient:     client,
+			RequestTimeout: requestTimeout,
+			ClientTimeout:  clientTimeout,
 		}, nil
 	case "local":
 		return &LocalLLM{
 			Endpoint:       cfg.LocalLLMEndpoint,
 			Model:          cfg.Model,
-			RequestTimeout: time.Duration(cfg.RequestTimeout) * time.Second,
-			ClientTimeout:  time.Duration(cfg.ClientTimeout) * time.Second,
+			RequestTimeout: requestTimeout,
+			ClientTimeout:  clientTimeout,
+			Headers:        localHeaders(cfg),
+			MaxRetries:     cfg.MaxRetries,
+			HTTPClient:     client,
 		}, nil
 	default:
 		return nil, fmt.Errorf("unsupported LLM API: %s", cfg.LLMAPI)
 	}
 }
 
+// protectedHeaders are owned by the request itself; custom headers may not replace
+// them or the body would no longer be understood by the provider.
+var protectedHeaders = map[string]bool{
+	"Content-Type":      true,
+	"Content-Length":    true,
+	"Host":              true,
+	"Transfer-Encoding": true,
+}
+
+// applyCustomHeaders sets the user's custom_headers on an outgoing request.
+// They are applied last so gateways can replace auth headers, but never the
+// protected ones above.
+func applyCustomHeaders(req *http.Request, headers map[string]string) {
+	for k, v := range headers {
+		if protectedHeaders[http.CanonicalHeaderKey(k)] {
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+}
+
+// localHeaders combines custom_headers with local_headers for the local
+// provider, local_headers winning. ${VAR} references in local_headers values
+// are expanded from the environment so tokens can stay out of the config file.
+func localHeaders(cfg *config.Config) map[string]string {
+	headers := make(map[string]string, len(cfg.CustomHeaders)+len(cfg.LocalHeaders))
+	for k, v := range cfg.CustomHeaders {
+		headers[k] = v
+	}
+	for k, v := range cfg.LocalHeaders {
+		headers[k] = os.ExpandEnv(v)
+	}
+	return headers
+}
+
 // ─── LOCAL LLM
 
 type LocalLLM struct {
@@ -49,6 +183,9 @@ type LocalLLM struct {
 	Model          string
 	RequestTimeout time.Duration
 	ClientTimeout  time.Duration
+	Headers        map[string]string
+	MaxRetries     int
+	HTTPClient     *http.Client
 }
 
 type localLLMRequest struct {
@@ -62,7 +199,24 @@ type localLLMResponse struct {
 	} `json:"choices"`
 }
 
-func (l *LocalLLM) GenerateCommand(prompt string) (string, error) {
+// LocalFormat describes the request payload LocalLLM sends to endpoint, as
+// detected from its path.
+func LocalFormat(endpoint string) string {
+	switch {
+	case strings.Contains(endpoint, "/api/generate"):
+		return "Ollama generate (/api/generate)"
+	case strings.Contains(endpoint, "/api/chat"):
+		return "Ollama chat (/api/chat)"
+	case strings.Contains(endpoint, "/v1/chat/completions"):
+		return "OpenAI-compatible chat (/v1/chat/completions)"
+	case strings.Contains(endpoint, "/v1/completions"):
+		return "OpenAI-compatible completions (/v1/completions)"
+	default:
+		return "OpenAI-compatible chat (default)"
+	}
+}
+
+func (l *LocalLLM) GenerateCommand(ctx context.Context, prompt string) (string, error) {
 	if l.Endpoint == "" {
 		return "", fmt.Errorf(
 			"Local LLM endpoint not configured.\n\n" +
@@ -148,7 +302,7 @@ func (l *LocalLLM) GenerateCommand(prompt string) (string, error) {
 		clientTimeout = 65 * time.Second
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, "POST", l.Endpoint, bytes.NewBuffer(jsonData))
@@ -156,9 +310,16 @@ func (l *LocalLLM) GenerateCommand(prompt string) (string, error) {
 		return "", fmt.Errorf("create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	applyCustomHeaders(req, l.Headers)
+
+	// a stopped server should fail now, not after the full client timeout
+	if err := checkLocalReachable(ctx, l.HTTPClient, req); err != nil {
+		return "", err
+	}
 
-	client := &http.Client{Timeout: clientTimeout}
-	resp, err := client.Do(req)
+	client := *clientOrDefault(l.HTTPClient)
+	client.Timeout = clientTimeout
+	resp, err := doWithRetry(&client, req, l.MaxRetries)
 	if err != nil {
 		return "", fmt.Errorf("request failed: %w", err)
 	}
@@ -272,13 +433,33 @@ func (l *LocalLLM) GenerateCommand(prompt string) (string, error) {
 // ─── OPENAI
 
 type OpenAI struct {
-	APIKey string
-	Model  string
+	APIKey         string
+	Model          string
+	Headers        map[string]string
+	MaxRetries     int
+	HTTPClient     *http.Client
+	RequestTimeout time.Duration
+	ClientTimeout  time.Duration
+	Temperature    *float64
+	TopP           *float64
+	// BaseURL and Path replace the default host and /v1/chat/completions for
+	// OpenAI-compatible services. Path may contain {model} and a query string.
+	BaseURL string
+	Path    string
+	// Stream writes the answer to Output token by token as it arrives.
+	Stream bool
+	Output io.Writer
+
+	usage *Usage
 }
 
 type openAIRequest struct {
-	Model    string          `json:"model"`
-	Messages []openAIMessage `json:"messages"`
+	Model       string          `json:"model"`
+	Messages    []openAIMessage `json:"messages"`
+	Stream      bool            `json:"stream,omitempty"`
+	Temperature *float64        `json:"temperature,omitempty"`
+	TopP        *float64        `json:"top_p,omitempty"`
+	N           int             `json:"n,omitempty"`
 }
 
 type openAIMessage struct {
@@ -290,11 +471,73 @@ type openAIResponse struct {
 	Choices []struct {
 		Message openAIMessage `json:"message"`
 	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// usage converts the reported usage, if any.
+func (r openAIResponse) usage() *Usage {
+	if r.Usage == nil {
+		return nil
+	}
+	return &Usage{
+		PromptTokens:     r.Usage.PromptTokens,
+		CompletionTokens: r.Usage.CompletionTokens,
+		TotalTokens:      r.Usage.TotalTokens,
+	}
+}
+
+type openAIStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+}
+
+func (o *OpenAI) endpoint() string {
+	base := "https://api.openai.com"
+	if o.BaseURL != "" {
+		base = strings.TrimRight(o.BaseURL, "/")
+	}
+	path := "/v1/chat/completions"
+	if o.Path != "" {
+		path = "/" + strings.TrimLeft(strings.ReplaceAll(o.Path, "{model}", url.PathEscape(o.Model)), "/")
+	}
+	return base + path
+}
+
+func (o *OpenAI) LastUsage() (Usage, bool) {
+	if o.usage == nil {
+		return Usage{}, false
+	}
+	return *o.usage, true
 }
 
-func (o *OpenAI) GenerateCommand(prompt string) (string, error) {
+func (o *OpenAI) Streaming() bool {
+	return o.Stream && o.Output != nil
+}
+
+func (o *OpenAI) GenerateCommand(ctx context.Context, prompt string) (string, error) {
+	answers, err := o.generate(ctx, prompt, 1)
+	if err != nil {
+		return "", err
+	}
+	return answers[0], nil
+}
+
+// GenerateCommands asks for n alternative answers using the n parameter.
+// Answers are never streamed, since they would interleave.
+func (o *OpenAI) GenerateCommands(ctx context.Context, prompt string, n int) ([]string, error) {
+	return o.generate(ctx, prompt, max(n, 1))
+}
+
+func (o *OpenAI) generate(ctx context.Context, prompt string, n int) ([]string, error) {
 	if o.APIKey == "" {
-		return "", fmt.Errorf(
+		return nil, fmt.Errorf(
 			"OpenAI API key not configured.\n\n" +
 				"Quick setup:\n" +
 				"  → Run: oneliner setup\n\n" +
@@ -311,66 +554,198 @@ func (o *OpenAI) GenerateCommand(prompt string) (string, error) {
 		Messages: []openAIMessage{
 			{Role: "user", Content: prompt},
 		},
+		Stream:      o.Streaming() && n == 1,
+		Temperature: o.Temperature,
+		TopP:        o.TopP,
+	}
+	if n > 1 {
+		reqBody.N = n
 	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(jsonData))
+	ctx, cancel := newRequestContext(ctx, o.RequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", o.endpoint(), bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+o.APIKey)
+	applyCustomHeaders(req, o.Headers)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	client := clientWithTimeout(o.HTTPClient, o.ClientTimeout)
+	resp, err := doWithRetry(client, req, o.MaxRetries)
 	if err != nil {
-		return "", fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
-		return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	if reqBody.Stream {
+		answer, err := o.readStream(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return []string{answer}, nil
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("OpenAI API error: %s", string(body))
+		return nil, fmt.Errorf("OpenAI API error: %s", string(body))
 	}
 
 	var result openAIResponse
 	if err := json.Unmarshal(body, &result); err != nil {
-		return "", err
+		// proxies occasionally hand back truncated or slightly mangled JSON;
+		// salvage the message content if it is intact before giving up
+		if content, ok := extractContentField(body); ok {
+			return []string{content}, nil
+		}
+		return nil, fmt.Errorf("failed to parse OpenAI response: %w\nraw body: %s", err, string(body))
 	}
 
 	if len(result.Choices) == 0 {
+		return nil, fmt.Errorf("no response from OpenAI")
+	}
+
+	o.usage = result.usage()
+	answers := make([]string, len(result.Choices))
+	for i, choice := range result.Choices {
+		answers[i] = choice.Message.Content
+	}
+	return answers, nil
+}
+
+// readStream consumes a server-sent events body, echoing each content delta to
+// o.Output and returning the assembled answer.
+func (o *OpenAI) readStream(body io.Reader) (string, error) {
+	var out strings.Builder
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		data, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk openAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			continue
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			out.WriteString(choice.Delta.Content)
+			fmt.Fprint(o.Output, choice.Delta.Content)
+		}
+	}
+	if out.Len() > 0 {
+		fmt.Fprintln(o.Output)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("reading stream: %w", err)
+	}
+	if strings.TrimSpace(out.String()) == "" {
 		return "", fmt.Errorf("no response from OpenAI")
 	}
 
-	return result.Choices[0].Message.Content, nil
+	return out.String(), nil
+}
+
+// extractContentField scans body for the first complete "content" string
+// value without requiring the surrounding JSON to be valid.
+func extractContentField(body []byte) (string, bool) {
+	const key = `"content"`
+	rest := body
+	for {
+		idx := bytes.Index(rest, []byte(key))
+		if idx < 0 {
+			return "", false
+		}
+		rest = rest[idx+len(key):]
+
+		value := bytes.TrimLeft(rest, " \t\r\n")
+		if len(value) == 0 || value[0] != ':' {
+			continue
+		}
+		value = bytes.TrimLeft(value[1:], " \t\r\n")
+		if len(value) == 0 || value[0] != '"' {
+			continue
+		}
+
+		// find the closing quote, skipping escaped characters
+		end := -1
+		for i := 1; i < len(value); i++ {
+			if value[i] == '\\' {
+				i++
+				continue
+			}
+			if value[i] == '"' {
+				end = i
+				break
+			}
+		}
+		if end < 0 {
+			return "", false
+		}
+
+		var content string
+		if err := json.Unmarshal(value[:end+1], &content); err != nil {
+			return "", false
+		}
+		if strings.TrimSpace(content) == "" {
+			return "", false
+		}
+		return content, true
+	}
 }
 
 // ─── CLAUDE
 
 type Claude struct {
-	APIKey    string
-	Model     string
-	MaxTokens int
+	APIKey         string
+	Model          string
+	MaxTokens      int
+	Headers        map[string]string
+	MaxRetries     int
+	HTTPClient     *http.Client
+	RequestTimeout time.Duration
+	ClientTimeout  time.Duration
+	Temperature    *float64
+	TopP           *float64
+
+	usage *Usage
 }
 
 type claudeRequest struct {
-	Model     string          `json:"model"`
-	Messages  []claudeMessage `json:"messages"`
-	MaxTokens int             `json:"max_tokens"`
+	Model       string          `json:"model"`
+	System      string          `json:"system,omitempty"`
+	Messages    []claudeMessage `json:"messages"`
+	MaxTokens   int             `json:"max_tokens"`
+	Temperature *float64        `json:"temperature,omitempty"`
+	TopP        *float64        `json:"top_p,omitempty"`
 }
 
 type claudeMessage struct {
@@ -382,9 +757,13 @@ type claudeResponse struct {
 	Content []struct {
 		Text string `json:"text"`
 	} `json:"content"`
+	Usage *struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
 }
 
-func (c *Claude) GenerateCommand(prompt string) (string, error) {
+func (c *Claude) GenerateCommand(ctx context.Context, prompt string) (string, error) {
 	if c.APIKey == "" {
 		return "", fmt.Errorf(
 			"Claude API key not configured.\n\n" +
@@ -403,12 +782,19 @@ func (c *Claude) GenerateCommand(prompt string) (string, error) {
 		maxTokens = 1024 // fallback default
 	}
 
+	// Claude follows instructions more closely when the role framing is sent
+	// as the system prompt rather than as part of the user turn
+	system, task := promptpkg.Split(prompt)
+
 	reqBody := claudeRequest{
-		Model: c.Model,
+		Model:  c.Model,
+		System: system,
 		Messages: []claudeMessage{
-			{Role: "user", Content: prompt},
+			{Role: "user", Content: task},
 		},
-		MaxTokens: maxTokens,
+		MaxTokens:   maxTokens,
+		Temperature: c.Temperature,
+		TopP:        c.TopP,
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -416,7 +802,10 @@ func (c *Claude) GenerateCommand(prompt string) (string, error) {
 		return "", err
 	}
 
-	req, err := http.NewRequest("POST", "https://api.anthropic.com/v1/messages", bytes.NewBuffer(jsonData))
+	ctx, cancel := newRequestContext(ctx, c.RequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.anthropic.com/v1/messages", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", err
 	}
@@ -424,9 +813,10 @@ func (c *Claude) GenerateCommand(prompt string) (string, error) {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("x-api-key", c.APIKey)
 	req.Header.Set("anthropic-version", "2023-06-01")
+	applyCustomHeaders(req, c.Headers)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	client := clientWithTimeout(c.HTTPClient, c.ClientTimeout)
+	resp, err := doWithRetry(client, req, c.MaxRetries)
 	if err != nil {
 		return "", fmt.Errorf("request failed: %w", err)
 	}
@@ -455,5 +845,306 @@ func (c *Claude) GenerateCommand(prompt string) (string, error) {
 		return "", fmt.Errorf("no response from Claude")
 	}
 
+	c.usage = nil
+	if result.Usage != nil {
+		c.usage = &Usage{
+			PromptTokens:     result.Usage.InputTokens,
+			CompletionTokens: result.Usage.OutputTokens,
+			TotalTokens:      result.Usage.InputTokens + result.Usage.OutputTokens,
+		}
+	}
 	return result.Content[0].Text, nil
 }
+
+func (c *Claude) LastUsage() (Usage, bool) {
+	if c.usage == nil {
+		return Usage{}, false
+	}
+	return *c.usage, true
+}
+
+// ─── MISTRAL
+
+// Mistral talks to Mistral's OpenAI-compatible chat completions API.
+type Mistral struct {
+	APIKey         string
+	Model          string
+	Headers        map[string]string
+	MaxRetries     int
+	HTTPClient     *http.Client
+	RequestTimeout time.Duration
+	ClientTimeout  time.Duration
+	Temperature    *float64
+	TopP           *float64
+
+	usage *Usage
+}
+
+func (m *Mistral) LastUsage() (Usage, bool) {
+	if m.usage == nil {
+		return Usage{}, false
+	}
+	return *m.usage, true
+}
+
+func (m *Mistral) GenerateCommand(ctx context.Context, prompt string) (string, error) {
+	if m.APIKey == "" {
+		return "", fmt.Errorf(
+			"Mistral API key not configured.\n\n" +
+				"Quick setup:\n" +
+				"  → Run: oneliner setup\n\n" +
+				"Or manually configure:\n" +
+				"  → oneliner config set llm_api mistral\n" +
+				"  → oneliner config set api_key xxxx\n" +
+				"  → oneliner config set model mistral-large-latest\n\n" +
+				"Get your API key: https://console.mistral.ai/api-keys",
+		)
+	}
+
+	reqBody := openAIRequest{
+		Model: m.Model,
+		Messages: []openAIMessage{
+			{Role: "user", Content: prompt},
+		},
+		Temperature: m.Temperature,
+		TopP:        m.TopP,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := newRequestContext(ctx, m.RequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.mistral.ai/v1/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+m.APIKey)
+	applyCustomHeaders(req, m.Headers)
+
+	client := clientWithTimeout(m.HTTPClient, m.ClientTimeout)
+	resp, err := doWithRetry(client, req, m.MaxRetries)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var result openAIResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", err
+	}
+
+	if len(result.Choices) == 0 {
+		return "", fmt.Errorf("no response from Mistral")
+	}
+
+	m.usage = result.usage()
+	return result.Choices[0].Message.Content, nil
+}
+
+// ─── COHERE
+
+type Cohere struct {
+	APIKey         string
+	Model          string
+	Headers        map[string]string
+	MaxRetries     int
+	HTTPClient     *http.Client
+	RequestTimeout time.Duration
+	ClientTimeout  time.Duration
+}
+
+type cohereRequest struct {
+	Model   string `json:"model"`
+	Message string `json:"message"`
+}
+
+type cohereResponse struct {
+	Text string `json:"text"`
+}
+
+func (c *Cohere) GenerateCommand(ctx context.Context, prompt string) (string, error) {
+	if c.APIKey == "" {
+		return "", fmt.Errorf(
+			"Cohere API key not configured.\n\n" +
+				"Quick setup:\n" +
+				"  → Run: oneliner setup\n\n" +
+				"Or manually configure:\n" +
+				"  → oneliner config set llm_api cohere\n" +
+				"  → oneliner config set api_key xxxx\n" +
+				"  → oneliner config set model command-r-plus\n\n" +
+				"Get your API key: https://dashboard.cohere.com/api-keys",
+		)
+	}
+
+	reqBody := cohereRequest{
+		Model:   c.Model,
+		Message: prompt,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := newRequestContext(ctx, c.RequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.cohere.ai/v1/chat", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	applyCustomHeaders(req, c.Headers)
+
+	client := clientWithTimeout(c.HTTPClient, c.ClientTimeout)
+	resp, err := doWithRetry(client, req, c.MaxRetries)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var result cohereResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", err
+	}
+
+	if strings.TrimSpace(result.Text) == "" {
+		return "", fmt.Errorf("no response from Cohere")
+	}
+
+	return result.Text, nil
+}
+
+// ─── GEMINI
+
+type Gemini struct {
+	APIKey         string
+	Model          string
+	Headers        map[string]string
+	MaxRetries     int
+	HTTPClient     *http.Client
+	RequestTimeout time.Duration
+	ClientTimeout  time.Duration
+}
+
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiRequest struct {
+	Contents []geminiContent `json:"contents"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content geminiContent `json:"content"`
+	} `json:"candidates"`
+}
+
+func (g *Gemini) GenerateCommand(ctx context.Context, prompt string) (string, error) {
+	if g.APIKey == "" {
+		return "", fmt.Errorf(
+			"Gemini API key not configured.\n\n" +
+				"Quick setup:\n" +
+				"  → Run: oneliner setup\n\n" +
+				"Or manually configure:\n" +
+				"  → oneliner config set llm_api gemini\n" +
+				"  → oneliner config set api_key xxxx\n" +
+				"  → oneliner config set model gemini-1.5-flash\n\n" +
+				"Get your API key: https://aistudio.google.com/app/apikey",
+		)
+	}
+
+	reqBody := geminiRequest{
+		Contents: []geminiContent{
+			{Role: "user", Parts: []geminiPart{{Text: prompt}}},
+		},
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := fmt.Sprintf(
+		"https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s",
+		url.PathEscape(g.Model), url.QueryEscape(g.APIKey),
+	)
+
+	ctx, cancel := newRequestContext(ctx, g.RequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	applyCustomHeaders(req, g.Headers)
+
+	client := clientWithTimeout(g.HTTPClient, g.ClientTimeout)
+	resp, err := doWithRetry(client, req, g.MaxRetries)
+	if err != nil {
+		// the request URL carries the API key, keep it out of the error
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var result geminiResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", err
+	}
+
+	if len(result.Candidates) == 0 || len(result.Candidates[0].Content.Parts) == 0 {
+		return "", fmt.Errorf("no response from Gemini")
+	}
+
+	return result.Candidates[0].Content.Parts[0].Text, nil
+}
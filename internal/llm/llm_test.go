@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExtractContentField(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		want   string
+		wantOK bool
+	}{
+		{"truncated after content", `{"choices":[{"message":{"role":"assistant","content":"ls -la"}}],"usa`, "ls -la", true},
+		{"escapes", `{"choices":[{"message":{"content":"grep \"a\\tb\" f\nEXPLANATION: x"}`, "grep \"a\\tb\" f\nEXPLANATION: x", true},
+		{"whitespace around colon", `{"message": {"content" :  "pwd"`, "pwd", true},
+		{"skips non-string content", `{"content": null, "message": {"content": "uptime"`, "uptime", true},
+		{"truncated inside content", `{"choices":[{"message":{"content":"find . -na`, "", false},
+		{"empty content", `{"choices":[{"message":{"content":""}}`, "", false},
+		{"no content", `{"error": {"message": "bad gateway"`, "", false},
+		{"html", `<html><body>502 Bad Gateway</body></html>`, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := extractContentField([]byte(tt.body))
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("extractContentField(%q) = %q, %v; want %q, %v", tt.body, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestOpenAITruncatedBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    string
+		wantErr string
+	}{
+		{"salvaged", `{"id":"x","choices":[{"index":0,"message":{"role":"assistant","content":"du -sh *"}}],"usage":{"prompt_`, "du -sh *", ""},
+		{"unsalvageable", `{"id":"x","choices":[{"index":0,"message":{"role":"assis`, "", `raw body: {"id":"x"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			o := &OpenAI{APIKey: "test", Model: "test-model", BaseURL: server.URL, MaxRetries: -1, HTTPClient: server.Client()}
+			got, err := o.GenerateCommand(context.Background(), "list file sizes")
+			if tt.wantErr == "" {
+				if err != nil || got != tt.want {
+					t.Errorf("GenerateCommand = %q, %v; want %q", got, err, tt.want)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("GenerateCommand error = %v, want it to include %q", err, tt.wantErr)
+			}
+		})
+	}
+}
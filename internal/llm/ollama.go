@@ -0,0 +1,62 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// IsOllamaEndpoint reports whether endpoint looks like an Ollama server: one of
+// its /api/ routes or the default port 11434.
+func IsOllamaEndpoint(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return strings.HasPrefix(u.Path, "/api/") || u.Port() == "11434"
+}
+
+// ListOllamaModels returns the models installed on the Ollama server behind
+// endpoint, using GET /api/tags. ctx should carry a short timeout since this
+// is only used to offer suggestions.
+func ListOllamaModels(ctx context.Context, endpoint string) ([]string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q", endpoint)
+	}
+	tags := url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/api/tags"}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", tags.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error (status %d)", resp.StatusCode)
+	}
+
+	var result struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	models := make([]string, 0, len(result.Models))
+	for _, m := range result.Models {
+		if m.Name != "" {
+			models = append(models, m.Name)
+		}
+	}
+	return models, nil
+}
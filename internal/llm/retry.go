@@ -0,0 +1,95 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	retryBaseDelay = 500 * time.Millisecond
+	// retryMaxDelay caps both the backoff and any Retry-After the server asks for.
+	retryMaxDelay = 30 * time.Second
+)
+
+// doWithRetry sends req, retrying up to maxRetries more times on network errors,
+// 5xx responses and 429s. Backoff doubles from 500ms; a 429's Retry-After header
+// wins when present. Waiting stops as soon as the request context is done.
+//
+// Non-retryable responses are returned as-is for the caller to inspect. When the
+// retries run out the last failure is returned as an error naming the attempts.
+func doWithRetry(client *http.Client, req *http.Request, maxRetries int) (*http.Response, error) {
+	maxRetries = max(maxRetries, 0)
+	delay := retryBaseDelay
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		resp, err := client.Do(req)
+		if err == nil && !retryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+		if err != nil && req.Context().Err() != nil {
+			return nil, err
+		}
+
+		wait := delay
+		if err == nil {
+			if after, ok := retryAfter(resp); ok {
+				wait = after
+			}
+		}
+
+		if attempt > maxRetries {
+			if attempt == 1 {
+				return resp, err
+			}
+			if err != nil {
+				return nil, fmt.Errorf("gave up after %d attempts: %w", attempt, err)
+			}
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+			resp.Body.Close()
+			return nil, fmt.Errorf("gave up after %d attempts: API error (status %d): %s", attempt, resp.StatusCode, string(body))
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+			resp.Body.Close()
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, errors.Join(req.Context().Err(), err)
+		case <-time.After(min(wait, retryMaxDelay)):
+		}
+		delay *= 2
+	}
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// retryAfter reads a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
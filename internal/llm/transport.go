@@ -0,0 +1,115 @@
+package llm
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/dorochadev/oneliner/config"
+)
+
+// newHTTPClient builds the client shared by all providers. Proxies come from
+// HTTP_PROXY/HTTPS_PROXY/NO_PROXY unless proxy_url overrides them, and TLS
+// verification can be switched off for internal proxies with self-signed certs.
+func newHTTPClient(cfg *config.Config) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+
+	if cfg.ProxyURL != "" {
+		proxy, err := url.Parse(cfg.ProxyURL)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid proxy_url %q: expected e.g. http://proxy.example.com:8080", cfg.ProxyURL)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	if cfg.InsecureTLS {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
+
+func clientOrDefault(client *http.Client) *http.Client {
+	if client != nil {
+		return client
+	}
+	return &http.Client{}
+}
+
+// newRequestContext bounds a whole request, including reading the response,
+// by request_timeout (60s when unset).
+func newRequestContext(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout == 0 {
+		timeout = 60 * time.Second
+	}
+	return context.WithTimeout(parent, timeout)
+}
+
+// clientWithTimeout returns a copy of client limited by client_timeout (65s when unset).
+func clientWithTimeout(client *http.Client, timeout time.Duration) *http.Client {
+	if timeout == 0 {
+		timeout = 65 * time.Second
+	}
+	c := *clientOrDefault(client)
+	c.Timeout = timeout
+	return &c
+}
+
+// providerTimeouts returns the request and client timeouts for cfg.LLMAPI. A
+// provider_timeouts entry replaces request_timeout for that provider, and the
+// client timeout is kept 5s above it so it never cuts the request short.
+func providerTimeouts(cfg *config.Config) (request, client time.Duration) {
+	request = time.Duration(cfg.RequestTimeout) * time.Second
+	client = time.Duration(cfg.ClientTimeout) * time.Second
+
+	if seconds, ok := cfg.ProviderTimeouts[cfg.LLMAPI]; ok && seconds > 0 {
+		request = time.Duration(seconds) * time.Second
+		client = request + 5*time.Second
+	}
+	return request, client
+}
+
+// localDialTimeout bounds the reachability check before a local LLM request.
+const localDialTimeout = 2 * time.Second
+
+// CheckReachable dials the host behind endpoint, giving up after a short
+// timeout, so an unreachable server is reported quickly and clearly.
+func CheckReachable(ctx context.Context, endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid endpoint %q", endpoint)
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	dialer := net.Dialer{Timeout: localDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return fmt.Errorf("local LLM at %s is unreachable — is your server running? (%w)", endpoint, err)
+	}
+	conn.Close()
+	return nil
+}
+
+// checkLocalReachable runs CheckReachable for req unless it goes through a
+// proxy, in which case only the proxy can tell whether the server is up.
+func checkLocalReachable(ctx context.Context, client *http.Client, req *http.Request) error {
+	if t, ok := clientOrDefault(client).Transport.(*http.Transport); ok && t.Proxy != nil {
+		if proxy, err := t.Proxy(req); err != nil || proxy != nil {
+			return nil
+		}
+	}
+	return CheckReachable(ctx, req.URL.String())
+}
@@ -0,0 +1,155 @@
+package llm
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dorochadev/oneliner/config"
+)
+
+func TestProviderTimeouts(t *testing.T) {
+	overrides := map[string]int{"local": 180, "openai": 30, "claude": 0, "gemini": -5}
+
+	tests := []struct {
+		provider string
+		request  time.Duration
+		client   time.Duration
+	}{
+		{"local", 180 * time.Second, 185 * time.Second},
+		{"openai", 30 * time.Second, 35 * time.Second},
+		// unset, zero or negative overrides fall back to the global timeouts
+		{"mistral", 60 * time.Second, 65 * time.Second},
+		{"claude", 60 * time.Second, 65 * time.Second},
+		{"gemini", 60 * time.Second, 65 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.LLMAPI = tt.provider
+			cfg.RequestTimeout, cfg.ClientTimeout = 60, 65
+			cfg.ProviderTimeouts = overrides
+
+			request, client := providerTimeouts(cfg)
+			if request != tt.request || client != tt.client {
+				t.Errorf("providerTimeouts = %v, %v; want %v, %v", request, client, tt.request, tt.client)
+			}
+		})
+	}
+
+	cfg := config.Default()
+	cfg.ProviderTimeouts = nil
+	if request, client := providerTimeouts(cfg); request != 60*time.Second || client != 65*time.Second {
+		t.Errorf("without provider_timeouts: %v, %v; want the defaults 60s, 65s", request, client)
+	}
+}
+
+func TestNewAppliesProviderTimeouts(t *testing.T) {
+	cfg := config.Default()
+	cfg.ProviderTimeouts = map[string]int{"local": 180, "openai": 30}
+
+	cfg.LLMAPI = "local"
+	provider, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := provider.(*LocalLLM); l.RequestTimeout != 180*time.Second || l.ClientTimeout != 185*time.Second {
+		t.Errorf("local timeouts %v, %v; want 180s, 185s", l.RequestTimeout, l.ClientTimeout)
+	}
+
+	cfg.LLMAPI = "openai"
+	provider, err = New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o := provider.(*OpenAI); o.RequestTimeout != 30*time.Second || o.ClientTimeout != 35*time.Second {
+		t.Errorf("openai timeouts %v, %v; want 30s, 35s", o.RequestTimeout, o.ClientTimeout)
+	}
+
+	cfg.LLMAPI = "claude"
+	provider, err = New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := provider.(*Claude); c.RequestTimeout != 60*time.Second || c.ClientTimeout != 65*time.Second {
+		t.Errorf("claude timeouts %v, %v; want the global 60s, 65s", c.RequestTimeout, c.ClientTimeout)
+	}
+}
+
+// closedEndpoint returns an http:// endpoint on a port nothing listens on.
+func closedEndpoint(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return "http://" + addr + "/api/generate"
+}
+
+func TestCheckReachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		endpoint string
+		wantErr  string
+	}{
+		{"listening", server.URL + "/api/generate", ""},
+		{"closed port", closedEndpoint(t), "is unreachable"},
+		{"no host", "/api/generate", "invalid endpoint"},
+		{"not a url", "http://[::1", "invalid endpoint"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckReachable(context.Background(), tt.endpoint)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("CheckReachable(%q) = %v", tt.endpoint, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CheckReachable(%q) = %v, want an error containing %q", tt.endpoint, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLocalLLMUnreachable(t *testing.T) {
+	endpoint := closedEndpoint(t)
+	l := &LocalLLM{
+		Endpoint:       endpoint,
+		Model:          "llama3",
+		RequestTimeout: time.Minute,
+		ClientTimeout:  time.Minute,
+		MaxRetries:     -1,
+		HTTPClient:     &http.Client{Transport: &http.Transport{}},
+	}
+
+	start := time.Now()
+	_, err := l.GenerateCommand(context.Background(), "list files")
+	if err == nil || !strings.Contains(err.Error(), "is unreachable — is your server running?") {
+		t.Fatalf("GenerateCommand against a stopped server = %v, want the unreachable error", err)
+	}
+	if elapsed := time.Since(start); elapsed > localDialTimeout {
+		t.Errorf("reporting the stopped server took %v, want under %v", elapsed, localDialTimeout)
+	}
+
+	// behind a proxy only the proxy can answer, so the dial check is skipped
+	// and the request fails at the proxy instead
+	proxy, _ := url.Parse(closedEndpoint(t))
+	l.HTTPClient = &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxy)}}
+	if _, err := l.GenerateCommand(context.Background(), "list files"); err == nil || strings.Contains(err.Error(), "is unreachable") {
+		t.Errorf("GenerateCommand through a proxy = %v, want a proxy error without the reachability check", err)
+	}
+}
@@ -1,8 +1,13 @@
 package prompt
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"strings"
+	"text/template"
 
 	"github.com/dorochadev/oneliner/config"
 )
@@ -13,6 +18,28 @@ type Context struct {
 	CWD      string
 	Username string
 	Shell    string
+	// Distro is the Linux distribution and version, e.g. "ubuntu 22.04".
+	Distro string
+	// Tools lists the probe_tools found on PATH.
+	Tools []string
+}
+
+// Options selects which optional sections the generated answer should contain.
+type Options struct {
+	Explain   bool
+	Breakdown bool
+	// Annotate asks for brief inline '#' comments inside the command itself.
+	Annotate bool
+	// POSIX restricts the answer to portable POSIX sh (dash, busybox, bash).
+	POSIX bool
+	// AvoidDestructive is set when retrying after an answer was assessed as
+	// critical risk; it steers the model away from destructive operations.
+	AvoidDestructive bool
+	// Alternative asks for a different approach than the typical answer, as
+	// --regenerate does.
+	Alternative bool
+	// Previous is an earlier answer the alternative must differ from.
+	Previous string
 }
 
 const (
@@ -20,8 +47,22 @@ const (
 	minWordCount   = 2
 )
 
+// rolePrefix starts the role framing line that opens every prompt.
+const rolePrefix = "You are an expert in "
+
+// Split separates the role framing ("You are an expert in ...") at the start
+// of a prompt from the task that follows, for providers that take a separate
+// system prompt. system is empty when text has no framing line.
+func Split(text string) (system, user string) {
+	if !strings.HasPrefix(text, rolePrefix) {
+		return "", text
+	}
+	system, user, _ = strings.Cut(text, "\n")
+	return strings.TrimSpace(system), user
+}
+
 // Build constructs the prompt for the LLM. Returns an error if the query is too short or vague.
-func Build(ctx Context, cfg *config.Config, explain, breakdown bool) (string, error) {
+func Build(ctx Context, cfg *config.Config, opts Options) (string, error) {
 	trimmedQuery := strings.TrimSpace(ctx.Query)
 
 	// Validate query
@@ -37,19 +78,143 @@ func Build(ctx Context, cfg *config.Config, explain, breakdown bool) (string, er
 	var b strings.Builder
 	b.Grow(512) // pre allocate approximate size
 
-	b.WriteString(fmt.Sprintf("You are an expert in %s on %s systems.\n", shell, ctx.OS))
-	b.WriteString(fmt.Sprintf("Output only a single safe %s one-liner that accomplishes the following task:\n", shell))
-	b.WriteString(fmt.Sprintf("%s\n\n", trimmedQuery))
+	custom, err := renderTemplate(cfg.PromptTemplate, templateData{
+		Query:    trimmedQuery,
+		OS:       ctx.OS,
+		Distro:   ctx.Distro,
+		Shell:    shell,
+		CWD:      ctx.CWD,
+		Username: ctx.Username,
+		Tools:    strings.Join(ctx.Tools, ", "),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if custom != "" {
+		b.WriteString(strings.TrimRight(custom, "\n") + "\n")
+	} else {
+		b.WriteString(fmt.Sprintf(rolePrefix+"%s on %s systems.\n", shell, ctx.OS))
+		b.WriteString(fmt.Sprintf("Output only a single safe %s one-liner that accomplishes the following task:\n", shell))
+		b.WriteString(fmt.Sprintf("%s\n\n", trimmedQuery))
+
+		b.WriteString("System:\n")
+		b.WriteString(fmt.Sprintf("  OS: %s\n", ctx.OS))
+		if ctx.Distro != "" {
+			b.WriteString(fmt.Sprintf("  Distro: %s\n", ctx.Distro))
+		}
+		b.WriteString(fmt.Sprintf("  Dir: %s\n", ctx.CWD))
+		b.WriteString(fmt.Sprintf("  User: %s\n", ctx.Username))
+		b.WriteString(fmt.Sprintf("  Shell: %s\n", ctx.Shell))
+		if len(ctx.Tools) > 0 {
+			b.WriteString(fmt.Sprintf("  Available tools: %s\n", strings.Join(ctx.Tools, ", ")))
+		}
+	}
+
+	appendFewShotExamples(&b, cfg.FewShotExamples)
+
+	if opts.POSIX {
+		appendPOSIXInstructions(&b)
+	} else {
+		appendShellSpecificInstructions(&b, shell, cfg.ShellInstructions)
+	}
+	if opts.Annotate {
+		appendAnnotationInstructions(&b)
+	}
+	if opts.AvoidDestructive {
+		appendSafetyInstructions(&b)
+	}
+	if opts.Alternative {
+		appendAlternativeInstructions(&b, opts.Previous)
+	}
+	appendExplanationInstructions(&b, opts.Explain, opts.Breakdown)
+	if opts.Explain || opts.Breakdown {
+		appendLanguageInstructions(&b, cfg.Language)
+	}
+
+	return b.String(), nil
+}
+
+// BuildExplanation constructs a prompt asking the LLM to explain an existing command
+// instead of generating one. The answer follows the same EXPLANATION: format as
+// Build, followed by a BREAKDOWN: section when breakdown is set.
+func BuildExplanation(ctx Context, cfg *config.Config, command string, breakdown bool) string {
+	shell := cfg.DefaultShell
+	if shell == "" {
+		shell = "bash"
+	}
+
+	var b strings.Builder
+	b.Grow(512)
+
+	b.WriteString(fmt.Sprintf(rolePrefix+"%s on %s systems.\n", shell, ctx.OS))
+	b.WriteString(fmt.Sprintf("Explain the following %s command:\n", shell))
+	b.WriteString(fmt.Sprintf("%s\n\n", strings.TrimSpace(command)))
 
 	b.WriteString("System:\n")
 	b.WriteString(fmt.Sprintf("  OS: %s\n", ctx.OS))
 	b.WriteString(fmt.Sprintf("  Dir: %s\n", ctx.CWD))
-	b.WriteString(fmt.Sprintf("  User: %s\n", ctx.Username))
 	b.WriteString(fmt.Sprintf("  Shell: %s\n", ctx.Shell))
 
-	appendShellSpecificInstructions(&b, shell)
-	appendExplanationInstructions(&b, explain, breakdown)
+	b.WriteString(`Start your answer with 'EXPLANATION:' on its own line. Do NOT repeat the command.
+In the explanation:
+- Briefly describe what the command does overall
+- Mention what each main flag or pipe stage contributes
+- Explain *how* and *why* the command works
+Keep it under 4 sentences. Do NOT use code fences.
+`)
+	if breakdown {
+		b.WriteString(breakdownAfterExplanation)
+	}
+	appendLanguageInstructions(&b, cfg.Language)
+
+	return b.String()
+}
+
+// templateData is what a prompt_template file can refer to, e.g. {{.Query}}.
+type templateData struct {
+	Query string
+	OS    string
+	// Distro is the Linux distribution and version, "" on other systems.
+	Distro string
+	// Shell is the shell the command is generated for (default_shell).
+	Shell    string
+	CWD      string
+	Username string
+	// Tools is the comma-separated list of probe_tools found on PATH.
+	Tools string
+}
+
+// renderTemplate renders the prompt_template file at path. It returns "" when
+// no template is configured or the file does not exist, so the built-in
+// prompt is used instead; a template that fails to parse or run is an error.
+func renderTemplate(path string, data templateData) (string, error) {
+	if strings.TrimSpace(path) == "" {
+		return "", nil
+	}
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+
+	text, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read prompt_template: %w", err)
+	}
+
+	tmpl, err := template.New(filepath.Base(path)).Option("missingkey=error").Parse(string(text))
+	if err != nil {
+		return "", fmt.Errorf("invalid prompt_template: %w", err)
+	}
 
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("invalid prompt_template: %w", err)
+	}
 	return b.String(), nil
 }
 
@@ -66,15 +231,105 @@ func validateQuery(query string) error {
 	return nil
 }
 
-func appendShellSpecificInstructions(b *strings.Builder, shell string) {
-	switch strings.ToLower(shell) {
+// appendShellSpecificInstructions adds the nudge for shell. An entry in the
+// shell_instructions config replaces the built-in text for that shell. The
+// shell may be given as a path, e.g. /usr/bin/fish or pwsh.exe.
+func appendShellSpecificInstructions(b *strings.Builder, shell string, overrides map[string]string) {
+	shell = strings.TrimSuffix(strings.ToLower(filepath.Base(strings.TrimSpace(shell))), ".exe")
+
+	for name, text := range overrides {
+		if strings.EqualFold(strings.TrimSpace(name), shell) && strings.TrimSpace(text) != "" {
+			b.WriteString(strings.TrimSpace(text) + "\n")
+			return
+		}
+	}
+
+	switch shell {
 	case "fish":
 		b.WriteString("Use idiomatic fish syntax only.\n")
-	case "powershell":
+	case "powershell", "pwsh":
 		b.WriteString("Use idiomatic PowerShell. No bash.\n")
 	}
 }
 
+// maxFewShotChars caps the examples added to a prompt, roughly 500 tokens, so
+// a long list cannot crowd out the task on small local models.
+const maxFewShotChars = 2000
+
+// appendFewShotExamples adds the few_shot_examples as demonstrations, in
+// order, skipping any that would exceed maxFewShotChars.
+func appendFewShotExamples(b *strings.Builder, examples []config.FewShotExample) {
+	var block strings.Builder
+	for _, ex := range examples {
+		query, command := strings.TrimSpace(ex.Query), strings.TrimSpace(ex.Command)
+		if query == "" || command == "" {
+			continue
+		}
+		entry := fmt.Sprintf("Task: %s\nCommand: %s\n", query, command)
+		if block.Len()+len(entry) > maxFewShotChars {
+			continue
+		}
+		block.WriteString(entry)
+	}
+
+	if block.Len() == 0 {
+		return
+	}
+	b.WriteString("Examples of good answers (answer the task above the same way):\n")
+	b.WriteString(block.String())
+}
+
+func appendPOSIXInstructions(b *strings.Builder) {
+	b.WriteString(`The command must be strictly POSIX sh compatible so it runs unchanged on dash, busybox and bash.
+Do NOT use bashisms: no [[ ]], arrays, 'function' keyword, <<<, $'...', {a,b} brace expansion, &> or 'source'.
+`)
+}
+
+func appendSafetyInstructions(b *strings.Builder) {
+	b.WriteString(`A previous answer to this task was a destructive command. The task does not ask for that.
+Do NOT delete, overwrite, format or change permissions of system paths, the home directory or '/'.
+Prefer read-only or narrowly scoped commands; if a change is required, limit it to the exact files named in the task.
+`)
+}
+
+// appendAlternativeInstructions asks for another way to solve the task,
+// naming the previous answer when there is one so it is not repeated.
+func appendAlternativeInstructions(b *strings.Builder, previous string) {
+	b.WriteString("Provide a different approach than a typical answer, e.g. other tools or options.\n")
+	if previous = strings.TrimSpace(previous); previous != "" {
+		b.WriteString(fmt.Sprintf("Do NOT answer with this previous command: %s\n", previous))
+	}
+}
+
+func appendAnnotationInstructions(b *strings.Builder) {
+	b.WriteString(`Annotate the command with brief inline '#' comments explaining each part.
+You may split it across lines after a pipe, '&&' or '||' so each comment ends its own line.
+Comments must not change what the command does.
+`)
+}
+
+// appendLanguageInstructions asks for the prose sections in language. The
+// section headings stay in English because the answer is parsed by them.
+func appendLanguageInstructions(b *strings.Builder, language string) {
+	language = strings.TrimSpace(language)
+	if language == "" {
+		return
+	}
+	b.WriteString(fmt.Sprintf("Write the EXPLANATION and BREAKDOWN text in %s. ", language))
+	b.WriteString("Keep the command itself and the 'EXPLANATION:' and 'BREAKDOWN:' headings exactly as specified.\n")
+}
+
+// breakdownAfterExplanation asks for a BREAKDOWN: section following the explanation.
+const breakdownAfterExplanation = `After the explanation add a 'BREAKDOWN:' section on a new line.
+In 'BREAKDOWN:' provide a **detailed numbered pipeline** describing each stage of the command in execution order.
+- Include every relevant flag, pipe, redirection, or expansion.
+- Explain what data is input/output at each step.
+- Clarify how intermediate transformations work.
+- Use as many numbered points as necessary; don't limit to a small fixed number.
+- Each item should be 1-3 sentences, clear and precise.
+Do NOT use code fences. Keep the focus on teaching the command's mechanics.
+`
+
 func appendExplanationInstructions(b *strings.Builder, explain, breakdown bool) {
 	if explain && breakdown {
 		b.WriteString(`Output ONLY the command first (no code fences, no commentary before).
@@ -86,15 +341,8 @@ In the explanation:
 Do NOT restate the user's question or describe concepts generally.
 Keep it under 4 sentences.
 
-After the explanation add a 'BREAKDOWN:' section on a new line.
-In 'BREAKDOWN:' provide a **detailed numbered pipeline** describing each stage of the command in execution order.
-- Include every relevant flag, pipe, redirection, or expansion.
-- Explain what data is input/output at each step.
-- Clarify how intermediate transformations work.
-- Use as many numbered points as necessary; don't limit to a small fixed number.
-- Each item should be 1-3 sentences, clear and precise.
-Do NOT use code fences. Keep the focus on teaching the command's mechanics.
 `)
+		b.WriteString(breakdownAfterExplanation)
 		return
 	}
 
@@ -0,0 +1,67 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dorochadev/oneliner/config"
+)
+
+func TestAppendShellSpecificInstructions(t *testing.T) {
+	overrides := map[string]string{
+		"fish": "  I use fish 3.7; prefer string builtins.  ",
+		"ZSH":  "Use zsh globbing qualifiers where they help.",
+		"bash": "   ",
+	}
+
+	tests := []struct {
+		name      string
+		shell     string
+		overrides map[string]string
+		want      string
+	}{
+		{"built-in fish", "fish", nil, "Use idiomatic fish syntax only.\n"},
+		{"built-in powershell", "powershell", nil, "Use idiomatic PowerShell. No bash.\n"},
+		{"built-in pwsh", "pwsh.exe", nil, "Use idiomatic PowerShell. No bash.\n"},
+		{"no built-in for bash", "bash", nil, ""},
+		{"override replaces built-in", "fish", overrides, "I use fish 3.7; prefer string builtins.\n"},
+		{"shell given as a path", "/usr/bin/fish", overrides, "I use fish 3.7; prefer string builtins.\n"},
+		{"key is case-insensitive", "zsh", overrides, "Use zsh globbing qualifiers where they help.\n"},
+		{"blank override is ignored", "bash", overrides, ""},
+		{"other shells keep built-ins", "powershell", overrides, "Use idiomatic PowerShell. No bash.\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			appendShellSpecificInstructions(&b, tt.shell, tt.overrides)
+			if got := b.String(); got != tt.want {
+				t.Errorf("appendShellSpecificInstructions(%q) wrote %q, want %q", tt.shell, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildShellInstructions(t *testing.T) {
+	cfg := config.Default()
+	cfg.DefaultShell = "fish"
+	cfg.ShellInstructions = map[string]string{"fish": "I use fish 3.7."}
+	ctx := Context{Query: "list all files modified today", OS: "linux", Shell: "fish"}
+
+	text, err := Build(ctx, cfg, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "I use fish 3.7.") || strings.Contains(text, "Use idiomatic fish syntax only.") {
+		t.Errorf("prompt does not use the fish override:\n%s", text)
+	}
+
+	// --posix asks for sh, so shell-specific nudges would contradict it
+	text, err = Build(ctx, cfg, Options{POSIX: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(text, "I use fish 3.7.") {
+		t.Errorf("--posix prompt still has the fish instructions:\n%s", text)
+	}
+}
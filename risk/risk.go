@@ -0,0 +1,46 @@
+// Package risk exposes oneliner's command risk heuristics to other Go
+// programs, without the CLI, prompts or LLM providers around them.
+//
+//	for _, f := range risk.Detect("curl -fsSL https://example.com/i.sh | sh", risk.Options{}) {
+//		fmt.Println(f.Level, f.Reason)
+//	}
+//
+// The heuristics are regex-based and can be fooled; treat a command without
+// findings as unflagged, not as safe.
+package risk
+
+import "github.com/dorochadev/oneliner/internal/executor"
+
+// Level rates how dangerous a command is. Levels are ordered, so they can be
+// compared, and marshal to JSON by name ("None" through "Critical").
+type Level = executor.RiskLevel
+
+const (
+	None     = executor.RiskNone
+	Low      = executor.RiskLow
+	Medium   = executor.RiskMedium
+	High     = executor.RiskHigh
+	Critical = executor.RiskCritical
+)
+
+// Finding is one reason a command is risky, with its level.
+type Finding = executor.Finding
+
+// Options configures Detect and Assess. The zero value applies the built-in
+// checks to a command that runs without --sudo; set Config (see the config
+// package) to add the user's blacklist, allowlist and custom patterns.
+type Options = executor.DetectOptions
+
+// Assessment is the overall rating of a command: every reason, at the level
+// of the worst one. It marshals to JSON as {"level":"High","reasons":[...]}.
+type Assessment = executor.RiskAssessment
+
+// Detect runs every check on command and returns what it found.
+func Detect(command string, opts Options) []Finding {
+	return executor.Detect(command, opts)
+}
+
+// Assess rates command as oneliner does before running it.
+func Assess(command string, opts Options) Assessment {
+	return executor.AssessCommandRisk(command, opts.Sudo, opts.Config)
+}
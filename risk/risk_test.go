@@ -0,0 +1,62 @@
+package risk_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dorochadev/oneliner/risk"
+)
+
+func TestAssessZeroOptions(t *testing.T) {
+	tests := []struct {
+		command string
+		want    risk.Level
+	}{
+		{"rm -rf /", risk.Critical},
+		{"rm -fr /", risk.Critical},
+		{"rm -Rf /", risk.Critical},
+		{"rm -r -f /", risk.Critical},
+		{"ls && rm -rf /", risk.Critical},
+		{"rm -rf /tmp/x", risk.High},
+		{"rm -v -rf /tmp/x", risk.High},
+		{"rm /tmp/x", risk.None},
+		{"ls -la", risk.None},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			got := risk.Assess(tt.command, risk.Options{})
+			if got.Level != tt.want {
+				t.Errorf("Assess(%q).Level = %v, want %v (reasons %q)", tt.command, got.Level, tt.want, got.Reasons)
+			}
+		})
+	}
+}
+
+func TestDetectZeroOptions(t *testing.T) {
+	findings := risk.Detect("rm -rf /", risk.Options{})
+	if len(findings) == 0 {
+		t.Fatal("Detect(\"rm -rf /\") found nothing")
+	}
+	for _, f := range findings {
+		if f.Reason == "destructive rm command targeting critical path" && f.Level == risk.Critical {
+			return
+		}
+	}
+	t.Errorf("Detect(\"rm -rf /\") = %+v, want the critical path rm finding", findings)
+}
+
+func TestAssessmentJSON(t *testing.T) {
+	data, err := json.Marshal(risk.Assess("rm -rf /tmp/x", risk.Options{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded risk.Assessment
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal %s: %v", data, err)
+	}
+	if decoded.Level != risk.High {
+		t.Errorf("round-tripped level = %v, want High (json %s)", decoded.Level, data)
+	}
+}